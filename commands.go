@@ -0,0 +1,453 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"data-crawler/pkg/common"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+)
+
+// commands are run by `data-crawler <command> [args]`, instead of crawling.
+var commands = map[string]func(args []string) int{
+	"validate":  validateCmd,
+	"diff":      diffCmd,
+	"unchanged": unchangedCmd,
+	"published": publishedCmd,
+	"pack":      packCmd,
+	"publish":   publishCmd,
+}
+
+func validateCmd(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	logFormat := fs.String("log-format", common.LogFormatText, "Log format, text or json")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: data-crawler validate [output dir or package dir]...")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	logger, err := common.NewLogger(os.Stderr, common.LevelInfo, *logFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"output"}
+	}
+
+	pkgDirs, err := packageDirs(dirs)
+	if err != nil {
+		logger.Fatal("find packages failed", "error", err)
+	}
+
+	invalid := 0
+	for _, dir := range pkgDirs {
+		errs := common.ValidatePackage(dir)
+		for _, err := range errs {
+			logger.Error("invalid package", "dir", dir, "error", err)
+		}
+		if len(errs) > 0 {
+			invalid += 1
+			continue
+		}
+		logger.Info("package valid", "dir", dir)
+	}
+
+	if invalid > 0 {
+		logger.Fatal("validation failed", "invalid", invalid, "packages", len(pkgDirs))
+	}
+	return 0
+}
+
+// findPackages returns the package directories in `dir` keyed by their folder name,
+// `dir` itself if it's a package.
+func findPackages(dir string) (map[string]string, error) {
+	if _, err := os.Stat(filepath.Join(dir, "package.json")); err == nil {
+		return map[string]string{filepath.Base(dir): dir}, nil
+	}
+
+	found, err := filepath.Glob(filepath.Join(dir, "*", "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("%s: no package found", dir)
+	}
+
+	result := make(map[string]string)
+	for _, f := range found {
+		// skip temporary dirs of sinks, e.g. `.op.gg.tmp`
+		if name := filepath.Base(filepath.Dir(f)); !strings.HasPrefix(name, ".") {
+			result[name] = filepath.Dir(f)
+		}
+	}
+	return result, nil
+}
+
+// packageDirs expands output dirs into their package dirs, sorted.
+func packageDirs(dirs []string) ([]string, error) {
+	var result []string
+	for _, dir := range dirs {
+		found, err := findPackages(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range common.GetKeys(found) {
+			result = append(result, found[name])
+		}
+	}
+
+	sort.Strings(result)
+	return result, nil
+}
+
+func diffCmd(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := fs.String("format", "text", "Output format, text or json")
+	minShift := fs.Float64("min-win-rate-shift", 1, "Ignore win rate changes smaller than these percentage points")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: data-crawler diff [flags] <old> <new>")
+		fmt.Fprintln(fs.Output(), "Compares two output dirs, or two package dirs. Exits with 1 when they differ.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 2 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		return 2
+	}
+
+	oldDirs, err := findPackages(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	newDirs, err := findPackages(fs.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	// comparing two package dirs, whatever their folder names
+	if len(oldDirs) == 1 && len(newDirs) == 1 {
+		for _, o := range oldDirs {
+			for name := range newDirs {
+				oldDirs = map[string]string{name: o}
+			}
+		}
+	}
+
+	names := make(map[string]bool)
+	for name := range oldDirs {
+		names[name] = true
+	}
+	for name := range newDirs {
+		names[name] = true
+	}
+	sorted := common.GetKeys(names)
+	sort.Strings(sorted)
+
+	diffs := []*common.PackageDiff{}
+	for _, name := range sorted {
+		// a package missing on one side is compared with an empty one
+		pkgs := make([]*common.Package, 2)
+		for i, dir := range []string{oldDirs[name], newDirs[name]} {
+			pkgs[i] = &common.Package{Info: common.PkgInfo{PkgName: name}}
+			if len(dir) == 0 {
+				continue
+			}
+			if pkgs[i], err = common.LoadPackage(dir); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+		}
+
+		d := common.DiffPackages(pkgs[0], pkgs[1], *minShift)
+		if !d.Empty() {
+			diffs = append(diffs, d)
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(diffs)
+	} else {
+		for _, d := range diffs {
+			fmt.Print(d)
+		}
+	}
+
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// previousHash reads `contentHash` of `package.json` in a package tarball.
+func previousHash(tarball string) (string, error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return "", err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return "", fmt.Errorf("%s: no package.json", tarball)
+		}
+		if err != nil {
+			return "", err
+		}
+		if h.Name != "package/package.json" {
+			continue
+		}
+
+		var info common.PkgInfo
+		if err = json.NewDecoder(tr).Decode(&info); err != nil {
+			return "", fmt.Errorf("%s: %s", tarball, err)
+		}
+		return info.ContentHash, nil
+	}
+}
+
+func unchangedCmd(args []string) int {
+	fs := flag.NewFlagSet("unchanged", flag.ExitOnError)
+	stateFile := fs.String("state", common.DefaultPublishStateFile, "State `file` of published packages")
+	hash := fs.String("hash", "", "Content `hash` of the previous version, e.g. from npm view <name> contentHash")
+	previous := fs.String("previous", "", "Tarball of the previous version")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: data-crawler unchanged [flags] <package dir>")
+		fmt.Fprintln(fs.Output(), "Exits with 0 when the content is the same as the previous version, 1 otherwise.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	pkg, err := common.LoadPackage(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	cur := pkg.Info.ContentHash
+
+	prev := *hash
+	if len(*previous) > 0 {
+		if prev, err = previousHash(*previous); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	unchanged := len(cur) > 0 && cur == prev
+	if len(prev) == 0 {
+		state, err := common.LoadPublishState(*stateFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		unchanged = state.Unchanged(pkg.Info.PkgName, cur)
+	}
+
+	if unchanged {
+		fmt.Printf("%s: unchanged (%s)\n", pkg.Info.PkgName, cur)
+		return 0
+	}
+	fmt.Printf("%s: changed (%s)\n", pkg.Info.PkgName, cur)
+	return 1
+}
+
+func publishedCmd(args []string) int {
+	fs := flag.NewFlagSet("published", flag.ExitOnError)
+	stateFile := fs.String("state", common.DefaultPublishStateFile, "State `file` of published packages")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: data-crawler published [flags] <package dir>...")
+		fmt.Fprintln(fs.Output(), "Records the content hash of published packages.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	state, err := common.LoadPublishState(*stateFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	for _, dir := range fs.Args() {
+		pkg, err := common.LoadPackage(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		state.Set(pkg.Info.PkgName, pkg.Info.ContentHash)
+	}
+
+	if err = state.Save(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func packCmd(args []string) int {
+	fs := flag.NewFlagSet("pack", flag.ExitOnError)
+	outDir := fs.String("out", "dist", "Write tarballs into this `dir`")
+	jsonOutput := fs.Bool("json", false, "Print tarball details as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: data-crawler pack [flags] [output dir or package dir]...")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"output"}
+	}
+
+	pkgDirs, err := packageDirs(dirs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	tarballs := []*common.Tarball{}
+	for _, dir := range pkgDirs {
+		t, err := common.Pack(dir, *outDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		tarballs = append(tarballs, t)
+	}
+
+	if *jsonOutput {
+		out, _ := json.MarshalIndent(tarballs, "", "  ")
+		fmt.Println(string(out))
+		return 0
+	}
+	for _, t := range tarballs {
+		fmt.Printf("%s@%s\n  file: %s (%d files, %d bytes)\n  shasum: %s\n  integrity: %s\n",
+			t.Name, t.Version, t.File, len(t.Files), t.Size, t.Shasum, t.Integrity)
+	}
+	return 0
+}
+
+func publishCmd(args []string) int {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	registry := fs.String("registry", common.DefaultRegistry, "npm registry `url`")
+	tag := fs.String("tag", common.DefaultDistTag, "Dist-tag of the published versions")
+	patchTag := fs.Bool("patch-tag", true, "Also tag versions with their patch, e.g. patch-11.14")
+	outDir := fs.String("out", "dist", "Write tarballs into this `dir`")
+	stateFile := fs.String("state", common.DefaultPublishStateFile, "State `file` of published packages")
+	force := fs.Bool("force", false, "Publish even if the content is unchanged")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: data-crawler publish [flags] [output dir or package dir]...")
+		fmt.Fprintln(fs.Output(), "The auth token is read from NPM_TOKEN, or the user .npmrc.")
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	dirs := fs.Args()
+	if len(dirs) == 0 {
+		dirs = []string{"output"}
+	}
+
+	pkgDirs, err := packageDirs(dirs)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	reg, err := common.NewRegistry(*registry, common.NpmToken(*registry))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	state, err := common.LoadPublishState(*stateFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigCh
+		cancel()
+	}()
+
+	code := 0
+	for _, dir := range pkgDirs {
+		pkg, err := common.LoadPackage(dir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+		t, err := common.Pack(dir, *outDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+			continue
+		}
+
+		hash := pkg.Info.ContentHash
+		if !*force {
+			prev, err := reg.LatestContentHash(ctx, t.Name, *tag)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", t.Name, err)
+				code = 1
+				continue
+			}
+			if (len(hash) > 0 && hash == prev) || state.Unchanged(pkg.Info.PkgName, hash) {
+				fmt.Printf("%s: unchanged, skipped\n", t.Name)
+				continue
+			}
+		}
+
+		tags := []string{*tag}
+		if *patchTag {
+			tags = append(tags, common.PatchTag(t.Version))
+		}
+		if err = reg.Publish(ctx, t, tags); err != nil {
+			fmt.Fprintf(os.Stderr, "%s@%s: %s\n", t.Name, t.Version, err)
+			code = 1
+			continue
+		}
+		fmt.Printf("%s@%s: published, tags %s\n", t.Name, t.Version, strings.Join(tags, ","))
+
+		state.Set(pkg.Info.PkgName, hash)
+		if err = state.Save(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			code = 1
+		}
+	}
+
+	return code
+}
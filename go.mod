@@ -2,4 +2,7 @@ module data-crawler
 
 go 1.15
 
-require github.com/PuerkitoBio/goquery v1.6.0
+require (
+	github.com/PuerkitoBio/goquery v1.6.0
+	github.com/mattn/go-sqlite3 v1.14.6
+)
@@ -1,86 +1,441 @@
 package main
 
 import (
+	"context"
 	"data-crawler/pkg/common"
-	la "data-crawler/pkg/lolalytics"
-	mb "data-crawler/pkg/murderbridge"
-	op "data-crawler/pkg/opgg"
+	_ "data-crawler/pkg/lolalytics"
+	_ "data-crawler/pkg/murderbridge"
+	_ "data-crawler/pkg/opgg"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 )
 
+// commitTimeout bounds writing a package to all sinks, a write which has started is finished after SIGINT or `-timeout`.
+const commitTimeout = 5 * time.Minute
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := commands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
 	debugFlag := flag.Bool("debug", false, "only for debug")
 	opggFlag := flag.Bool("opgg", false, "Fetch & generate data from op.gg")
 	mbFlag := flag.Bool("mb", false, "Fetch & generate murderbridge.com")
 	laFlag := flag.Bool("la", false, "Fetch & generate lolalytics.com")
 	fetchAll := flag.Bool("a", false, "Fetch & generate data from all available sources")
+	sourcesFlag := flag.String("sources", "", "Comma separated source names or package names to fetch, e.g. opgg,lolalytics-aram")
+	modeFlag := flag.String("mode", "", "Only fetch sources supporting this game `mode`, classic or aram")
+	listFlag := flag.Bool("list", false, "List all available sources")
+	timeout := flag.Duration("timeout", 0, "Deadline of the whole crawl, 0 means no deadline")
+	httpTimeout := flag.Duration("http-timeout", common.DefaultTimeout, "Deadline of a single HTTP request attempt")
+	userAgent := flag.String("user-agent", "", "User-Agent header sent to upstream services")
+	proxy := flag.String("proxy", "", "Proxy `url` for all upstream requests")
+	recordDir := flag.String("record", "", "Record all upstream responses into a cassette `dir`")
+	rateLimit := flag.Float64("rate-limit", common.DefaultRateLimit, "Allowed requests per second for each upstream host, 0 means unlimited")
+	rateBurst := flag.Int("rate-burst", common.DefaultRateBurst, "Maximum requests sent to an upstream host at once")
+	retries := flag.Int("retries", common.DefaultRetryPolicy.Attempts, "Maximum attempts of a failed request, 1 means never retry")
+	retryDelay := flag.Duration("retry-delay", common.DefaultRetryPolicy.BaseDelay, "Base delay of the exponential backoff between retries")
+	sourceRetries := flag.String("source-retries", "", "Per source maximum attempts, e.g. op.gg=5,lolalytics=2")
+	concurrency := flag.Int("concurrency", common.DefaultConcurrency, "Maximum jobs each source runs at once")
+	sourceConcurrency := flag.String("source-concurrency", "", "Per source maximum jobs at once, e.g. op.gg=4,murderbridge=16")
+	maxFailureRate := flag.Float64("max-failure-rate", 0.2, "Exit with non-zero code when the failed jobs of any source exceed this ratio")
+	replayDir := flag.String("replay", "", "Serve all upstream responses from a cassette `dir`, without network access")
+	ddragonVersion := flag.String("ddragon-version", "", "Use this Data Dragon `version` instead of the latest available one")
+	dragontail := flag.String("dragontail", "", "Read Data Dragon files from an extracted dragontail `dir` or its .tgz, without network access")
+	locales := flag.String("locales", "", "Also emit champion names & titles in these locales, e.g. en_US,zh_CN,ko_KR")
+	ddragonCache := flag.String("ddragon-cache", common.DefaultDataDragonCacheDir, "Cache `dir` of Data Dragon files, empty to disable, unused with -record & -replay")
+	publishState := flag.String("publish-state", common.DefaultPublishStateFile, "State `file` of published packages, to mark unchanged ones in the run report")
+	logFormat := flag.String("log-format", common.LogFormatText, "Log format, text or json")
+	logLevel := flag.String("log-level", "info", "Minimum log level, debug, info, warn or error")
+	sinkFlag := flag.String("sink", common.OutputDir, "Comma separated output sinks, a dir, tar.gz:dir, zip:dir, sqlite:file or s3://bucket/prefix")
+	sqliteFile := flag.String("sqlite", "", "Also export builds & runes of all sources into this SQLite `file`")
 
 	flag.Parse()
-	fmt.Println(os.Args)
 
-	timestamp := time.Now().UTC().UnixNano() / int64(time.Millisecond)
-	allChampionData, officialVer, err := common.GetChampionList()
+	level, err := common.ParseLevel(*logLevel)
 	if err != nil {
 		log.Fatal(err)
 	}
-	runeLoopUp, allRunes, err := common.GetRunesReforged(officialVer)
+	logger, err := common.NewLogger(os.Stderr, level, *logFormat)
 	if err != nil {
 		log.Fatal(err)
 	}
+	common.Log = logger
+	logger.Debug("args", "args", strings.Join(os.Args[1:], " "))
+
+	report := common.RunReport{
+		Args:      os.Args[1:],
+		StartedAt: time.Now(),
+	}
+	// fatal still writes the run report, so scheduled jobs can tell why nothing was generated
+	fatal := func(msg string, err error, kv ...interface{}) {
+		report.Error = msg + ": " + err.Error()
+		report.ExitCode = 1
+		report.FinishedAt = time.Now()
+		report.Duration = report.FinishedAt.Sub(report.StartedAt)
+		if saveErr := report.Save(common.OutputDir); saveErr != nil {
+			logger.Error("write run report failed", "error", saveErr)
+		}
+		logger.Fatal(msg, append(kv, "error", err)...)
+	}
+
+	if *listFlag {
+		for _, s := range common.Sources() {
+			fmt.Printf("%-16s %-16s %v\n", s.Name(), s.PkgName(), s.Modes())
+		}
+		return
+	}
+
+	names := parseList(*sourcesFlag)
+	if *opggFlag {
+		names = append(names, `opgg`)
+	}
+	if *mbFlag {
+		names = append(names, `murderbridge`)
+	}
+	if *laFlag {
+		names = append(names, `lolalytics`)
+	}
+	if len(names) == 0 && !*fetchAll {
+		logger.Warn("no source selected, use `-a` to fetch all sources or `-list` to list them")
+		return
+	}
+	if *fetchAll {
+		names = nil
+	}
+
+	sources, err := common.SelectSources(names, common.Mode(*modeFlag))
+	if err != nil {
+		fatal("select sources failed", err)
+	}
+
+	sinks, err := common.ParseSinks(*sinkFlag)
+	if err != nil {
+		fatal("invalid flag", err, "flag", "sink")
+	}
+	if len(*sqliteFile) > 0 {
+		sqliteSink, err := common.NewSQLiteSink(*sqliteFile)
+		if err != nil {
+			fatal("invalid flag", err, "flag", "sqlite")
+		}
+		sinks = append(sinks, sqliteSink)
+	}
+
+	retryPolicy := common.DefaultRetryPolicy
+	retryPolicy.Attempts = *retries
+	retryPolicy.BaseDelay = *retryDelay
+	sourceAttempts, err := parseSourceValues(*sourceRetries)
+	if err != nil {
+		fatal("invalid flag", err, "flag", "source-retries")
+	}
+	sourceRetry := make(map[string]common.RetryPolicy)
+	for k, v := range sourceAttempts {
+		p := retryPolicy
+		p.Attempts = v
+		sourceRetry[k] = p
+	}
+	sourceJobs, err := parseSourceValues(*sourceConcurrency)
+	if err != nil {
+		fatal("invalid flag", err, "flag", "source-concurrency")
+	}
+
+	fetcher, err := common.NewFetcher(common.FetcherOptions{
+		Timeout:     *httpTimeout,
+		UserAgent:   *userAgent,
+		Proxy:       *proxy,
+		RecordDir:   *recordDir,
+		ReplayDir:   *replayDir,
+		RateLimit:   *rateLimit,
+		RateBurst:   *rateBurst,
+		Retry:       retryPolicy,
+		SourceRetry: sourceRetry,
+		Logger:      logger,
+	})
+	if err != nil {
+		fatal("create fetcher failed", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+
+	// on SIGINT/SIGTERM, cancel in-flight requests, packages of sources which didn't finish are not written
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		logger.Warn("received signal, cancelling", "signal", sig)
+		cancel()
+		signal.Stop(sigCh)
+	}()
+
+	timestamp := time.Now().UTC().UnixNano() / int64(time.Millisecond)
+	// cassettes must hold all Data Dragon files, which cache hits would bypass
+	cacheDir := *ddragonCache
+	if len(*recordDir) > 0 || len(*replayDir) > 0 {
+		cacheDir = ""
+	}
+	ddragon := common.NewDataDragon(fetcher, cacheDir)
+	if len(*dragontail) > 0 {
+		t, err := common.OpenDragontail(*dragontail)
+		if err != nil {
+			fatal("open dragontail failed", err, "path", *dragontail)
+		}
+		logger.Info("using dragontail", "path", *dragontail, "versions", strings.Join(t.Versions(), ","))
+		ddragon = ddragon.WithDragontail(t)
+	}
+	officialVer, err := ddragon.ResolveVersion(ctx, *ddragonVersion)
+	if err != nil {
+		fatal("resolve data dragon version failed", err)
+	}
+	report.OfficialVersion = officialVer
+	allChampionData, err := ddragon.GetChampionList(ctx, officialVer)
+	if err != nil {
+		fatal("get champion list failed", err)
+	}
+	runeLoopUp, allRunes, err := ddragon.GetRunesReforged(ctx, officialVer)
+	if err != nil {
+		fatal("get runes failed", err)
+	}
+	items, err := ddragon.GetItemList(ctx, officialVer)
+	if err != nil {
+		fatal("get items failed", err)
+	}
+
+	var localized *common.Localized
+	if localeList := parseList(*locales); len(localeList) > 0 {
+		localized, err = ddragon.GetLocalized(ctx, officialVer, localeList)
+		if err != nil {
+			fatal("get localized data failed", err)
+		}
+		for k, v := range allChampionData.Data {
+			v.Names = localized.ChampionNames(k)
+			v.Titles = localized.ChampionTitles(k)
+			allChampionData.Data[k] = v
+		}
+	}
+	championAliasList := common.MakeAliasList(allChampionData.Data, localized)
+
+	deps := &common.Deps{
+		Champions:       allChampionData.Data,
+		AliasList:       championAliasList,
+		OfficialVersion: officialVer,
+		Timestamp:       timestamp,
+		RuneLookUp:      runeLoopUp,
+		AllRunes:        allRunes,
+		Items:           items,
+		Fetcher:         fetcher,
+		Localized:       localized,
+		Debug:           *debugFlag,
+	}
+
+	results := make([]*common.Result, len(sources))
+	errs := make([]error, len(sources))
+	wg := new(sync.WaitGroup)
+
+	for i, s := range sources {
+		logger.Info("fetch data", "source", s.PkgName())
+
+		wg.Add(1)
+		go func(i int, s common.Source) {
+			defer wg.Done()
+
+			d := *deps
+			d.Fetcher = fetcher.ForSource(s)
+			d.DataDragon = ddragon.WithFetcher(d.Fetcher)
+			d.Concurrency = sourceValue(sourceJobs, s, *concurrency)
+			d.Logger = logger.With("source", s.PkgName())
+			if errs[i] = common.PrepareStaging(s.PkgName()); errs[i] == nil {
+				results[i], errs[i] = s.Fetch(ctx, &d)
+			}
+			if results[i] == nil {
+				results[i] = common.NewResult(s.PkgName())
+				results[i].Source = s.Name()
+				results[i].Finish()
+			}
+			results[i].OfficialVersion = officialVer
+			results[i].Stats = d.Fetcher.Stats()
+		}(i, s)
+	}
+	wg.Wait()
+
+	state, err := common.LoadPublishState(*publishState)
+	if err != nil {
+		logger.Warn("read publish state failed", "file", *publishState, "error", err)
+	}
+
+	exitCode := 0
+	for i, s := range sources {
+		r := results[i]
+		sr := common.SourceReport{Result: r}
+
+		sourceLogger := logger.With("source", s.PkgName())
+		if errs[i] != nil {
+			sourceLogger.Error("source failed", "error", errs[i])
+			sr.Error = errs[i].Error()
+			exitCode = 1
+		} else {
+			sourceLogger.Info("finished",
+				"attempted", r.Attempted,
+				"succeeded", r.Succeeded,
+				"failed", len(r.Failed),
+				"skipped", len(r.Skipped),
+				"duration", r.Duration,
+				"requests", r.Stats.Requests,
+				"retries", r.Stats.Retries,
+			)
+			for _, issue := range r.Failed {
+				sourceLogger.Warn("job failed", "champion", issue.Champion, "position", issue.Position, "url", issue.Url, "status", issue.StatusCode, "reason", issue.Reason)
+			}
 
-	var championAliasList = make(map[string]string)
-	for k, v := range allChampionData.Data {
-		championAliasList[v.Name] = k
+			sr.Healthy = r.FailureRate() <= *maxFailureRate
+			if !sr.Healthy {
+				sourceLogger.Error("failure rate exceeds threshold", "rate", r.FailureRate(), "threshold", *maxFailureRate)
+				exitCode = 1
+			}
+
+			if sr.Healthy && ctx.Err() != nil {
+				// jobs cut short are skipped, the package is incomplete & would replace a complete one
+				sourceLogger.Error("crawl cancelled, package not updated", "error", ctx.Err())
+				sr.Error = "crawl cancelled: " + ctx.Err().Error()
+				exitCode = 1
+			} else if sr.Healthy {
+				// SIGINT during the write doesn't abort it halfway
+				commitCtx, commitCancel := context.WithTimeout(context.Background(), commitTimeout)
+				err := commitPackage(commitCtx, s.PkgName(), allChampionData.Data, sinks, sourceLogger)
+				commitCancel()
+				if err != nil {
+					sourceLogger.Error("package not updated", "error", err)
+					sr.Error = err.Error()
+					exitCode = 1
+				} else {
+					sr.Updated = true
+				}
+			}
+
+			if pkg, err := common.LoadPackage(common.StagingDir(s.PkgName())); sr.Updated && err == nil {
+				sr.ContentHash = pkg.Info.ContentHash
+				sr.Unchanged = state != nil && state.Unchanged(s.PkgName(), sr.ContentHash)
+				if sr.Unchanged {
+					sourceLogger.Info("content unchanged since last publish", "hash", sr.ContentHash)
+				}
+			}
+		}
+
+		if !sr.Updated {
+			sourceLogger.Warn("package not written to all sinks")
+		}
+		_ = common.DiscardPackage(s.PkgName())
+
+		report.Sources = append(report.Sources, sr)
+	}
+
+	if err = common.CloseSinks(sinks); err != nil {
+		logger.Error("close sinks failed", "error", err)
+		exitCode = 1
+	}
+
+	report.OfficialVersion = officialVer
+	// sources count their own requests, `fetcher` only those made before sources start
+	report.Stats = fetcher.Stats()
+	for _, r := range results {
+		report.Stats = report.Stats.Add(r.Stats)
 	}
+	report.ExitCode = exitCode
+	report.FinishedAt = time.Now()
+	report.Duration = report.FinishedAt.Sub(report.StartedAt)
+	if err = report.Save(common.OutputDir); err != nil {
+		logger.Error("write run report failed", "error", err)
+	}
+
+	os.Exit(exitCode)
+}
 
-	ch := make(chan string)
-	var opggRet, mbRet, opggAramRet, laRet, laAramRet string
+// commitPackage writes `index.json` of the staged package `pkgName` once its source has finished,
+// and writes the package to all sinks if it passes validation.
+func commitPackage(ctx context.Context, pkgName string, champions map[string]common.ChampionItem, sinks []common.Sink, logger *common.Logger) error {
+	dir := common.StagingDir(pkgName)
+	if err := common.WriteIndex(dir, champions); err != nil {
+		return err
+	}
 
-	if *opggFlag || *fetchAll {
-		fmt.Println("[CMD] Fetch data from op.gg")
-		go func() {
-			ch <- op.Import(allChampionData.Data, championAliasList, officialVer, timestamp, *debugFlag)
-		}()
-		go func() {
-			ch <- op.ImportAram(allChampionData.Data, championAliasList, officialVer, timestamp, *debugFlag)
-		}()
+	if errs := common.ValidatePackage(dir); len(errs) > 0 {
+		for _, err := range errs {
+			logger.Error("invalid package", "error", err)
+		}
+		return fmt.Errorf("%s: %d validation errors", pkgName, len(errs))
 	}
 
-	if *mbFlag || *fetchAll {
-		fmt.Println("[CMD] Fetch data from murderbridge.com")
-		go func() {
-			ch <- mb.Import(allChampionData.Data, timestamp, runeLoopUp, allRunes, *debugFlag)
-		}()
+	var failed []string
+	for _, sink := range sinks {
+		if err := sink.Write(ctx, pkgName, dir); err != nil {
+			logger.Error("write package failed", "sink", sink.String(), "error", err)
+			failed = append(failed, sink.String())
+			continue
+		}
+		logger.Info("package written", "sink", sink.String())
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s: failed to write to %s", pkgName, strings.Join(failed, ","))
 	}
+	return nil
+}
+
+// parseSourceValues parses `op.gg=5,lolalytics=2` into a map keyed by source name or package name.
+func parseSourceValues(v string) (map[string]int, error) {
+	values := make(map[string]int)
+	for _, pair := range strings.Split(v, ",") {
+		pair = strings.TrimSpace(pair)
+		if len(pair) == 0 {
+			continue
+		}
 
-	if *laFlag || *fetchAll {
-		fmt.Println("[CMD] Fetch data from lolalytics.com")
-		go func() {
-			ch <- la.Import(allChampionData.Data, officialVer, timestamp, runeLoopUp, false, *debugFlag)
-		}()
-		go func() {
-			ch <- la.Import(allChampionData.Data, officialVer, timestamp, runeLoopUp, true, *debugFlag)
-		}()
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid source value: %s", pair)
+		}
+		n, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid source value: %s", pair)
+		}
+
+		values[kv[0]] = n
 	}
 
-	if *opggFlag || *fetchAll {
-		opggRet = <-ch
-		opggAramRet = <-ch
-		fmt.Println(opggRet)
-		fmt.Println(opggAramRet)
+	return values, nil
+}
+
+// parseList splits comma separated `v`, ignoring spaces & empty entries.
+func parseList(v string) []string {
+	var list []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); len(s) > 0 {
+			list = append(list, s)
+		}
 	}
-	if *mbFlag || *fetchAll {
-		mbRet = <-ch
-		fmt.Println(mbRet)
+	return list
+}
+
+// sourceValue looks up the value for `s` by package name first, then by source name.
+func sourceValue(values map[string]int, s common.Source, fallback int) int {
+	if v, ok := values[s.PkgName()]; ok {
+		return v
 	}
-	if *laFlag || *fetchAll {
-		laRet = <-ch
-		laAramRet = <-ch
-		fmt.Println(laRet)
-		fmt.Println(laAramRet)
+	if v, ok := values[s.Name()]; ok {
+		return v
 	}
+	return fallback
 }
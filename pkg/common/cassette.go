@@ -0,0 +1,124 @@
+package common
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// Cassette is the recorded meta of a single response,
+// the body lives next to it in `<key>.body`.
+type Cassette struct {
+	Method     string      `json:"method"`
+	Url        string      `json:"url"`
+	StatusCode int         `json:"statusCode"`
+	Status     string      `json:"status"`
+	Header     http.Header `json:"header"`
+}
+
+func cassetteKey(method string, url string) string {
+	sum := sha1.Sum([]byte(method + " " + url))
+	return hex.EncodeToString(sum[:])
+}
+
+// RecordTransport saves every response passing through it into `dir`.
+type RecordTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func NewRecordTransport(dir string, next http.RoundTripper) (*RecordTransport, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &RecordTransport{dir: dir, next: next}, nil
+}
+
+func (t *RecordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	c := Cassette{
+		Method:     req.Method,
+		Url:        req.URL.String(),
+		StatusCode: res.StatusCode,
+		Status:     res.Status,
+		Header:     res.Header,
+	}
+	key := cassetteKey(c.Method, c.Url)
+	if err = ioutil.WriteFile(filepath.Join(t.dir, key+".body"), body, 0644); err != nil {
+		return nil, err
+	}
+	if err = SaveJSON(filepath.Join(t.dir, key+".json"), c); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ReplayTransport serves responses recorded by `RecordTransport`, it never touches the network.
+type ReplayTransport struct {
+	dir string
+}
+
+func NewReplayTransport(dir string) (*ReplayTransport, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("cassette: %s is not a directory", dir)
+	}
+
+	return &ReplayTransport{dir: dir}, nil
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := cassetteKey(req.Method, req.URL.String())
+
+	meta, err := ioutil.ReadFile(filepath.Join(t.dir, key+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("cassette: no recorded response for %s %s", req.Method, req.URL)
+	}
+
+	var c Cassette
+	if err = json.Unmarshal(meta, &c); err != nil {
+		return nil, fmt.Errorf("cassette: broken record for %s: %s", req.URL, err)
+	}
+
+	body, err := ioutil.ReadFile(filepath.Join(t.dir, key+".body"))
+	if err != nil {
+		return nil, fmt.Errorf("cassette: missing body for %s: %s", req.URL, err)
+	}
+
+	return &http.Response{
+		Status:        c.Status,
+		StatusCode:    c.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        c.Header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
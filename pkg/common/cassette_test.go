@@ -0,0 +1,90 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestCassette_recordReplay(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/champions":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"Zed":"238"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte("not found"))
+		}
+	}))
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	record, err := NewFetcher(FetcherOptions{RecordDir: dir, Retry: RetryPolicy{Attempts: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if _, err = record.MakeRequest(ctx, srv.URL+"/champions"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = record.MakeRequest(ctx, srv.URL+"/missing"); err == nil {
+		t.Fatal("expected 404 to be recorded as an error")
+	}
+	// replay must not touch the network
+	srv.Close()
+
+	replay, err := NewFetcher(FetcherOptions{ReplayDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		url    string
+		body   string
+		status int
+		err    string
+	}{
+		{name: "recorded", url: srv.URL + "/champions", body: `{"Zed":"238"}`},
+		{name: "recorded error", url: srv.URL + "/missing", status: http.StatusNotFound},
+		{name: "not recorded", url: srv.URL + "/items", err: "no recorded response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := replay.MakeRequest(ctx, tt.url)
+			var httpErr *HTTPError
+			switch {
+			case len(tt.err) > 0:
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, expected %q", err, tt.err)
+				}
+			case tt.status > 0:
+				if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+					t.Fatalf("error %v, expected status %d", err, tt.status)
+				}
+			case err != nil:
+				t.Fatalf("unexpected error: %s", err)
+			case string(body) != tt.body:
+				t.Fatalf("body %s, expected %s", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestNewFetcher_recordAndReplay(t *testing.T) {
+	if _, err := NewFetcher(FetcherOptions{RecordDir: "a", ReplayDir: "b"}); err == nil {
+		t.Fatal("expected record & replay to be rejected together")
+	}
+}
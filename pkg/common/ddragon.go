@@ -0,0 +1,333 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+const DataDragonUrl = "https://ddragon.leagueoflegends.com"
+const DefaultLocale = `en_US`
+const DefaultDataDragonCacheDir = `.cache/ddragon`
+
+// MaxVersionFallback is how many older versions are tried when files of the newest one are missing.
+const MaxVersionFallback = 2
+
+// DataDragon fetches official data from Data Dragon, and keeps a copy of each file on disk,
+// in `<cacheDir>/<version>/<locale>/<file>`. Cached files are validated before being used,
+// an empty `cacheDir` disables the cache.
+// With a dragontail, files are read from it instead, without network access.
+type DataDragon struct {
+	fetcher    *Fetcher
+	cacheDir   string
+	dragontail *Dragontail
+}
+
+func NewDataDragon(f *Fetcher, cacheDir string) *DataDragon {
+	return &DataDragon{
+		fetcher:  f,
+		cacheDir: cacheDir,
+	}
+}
+
+// WithFetcher returns a client sharing the cache with `d`, but sending requests by `f`.
+func (d *DataDragon) WithFetcher(f *Fetcher) *DataDragon {
+	c := *d
+	c.fetcher = f
+	return &c
+}
+
+// WithDragontail returns a client reading all files from `t`.
+func (d *DataDragon) WithDragontail(t *Dragontail) *DataDragon {
+	c := *d
+	c.dragontail = t
+	return &c
+}
+
+// getJSON reads `file` of `version` & `locale` into `v` from the cache, or from Data Dragon.
+// `validate` rejects broken or unexpected content, in which case the cache is refreshed.
+func (d *DataDragon) getJSON(ctx context.Context, version string, locale string, file string, v interface{}, validate func() error) error {
+	if d.dragontail != nil {
+		body, err := d.dragontail.ReadFile(version, locale, file)
+		if err != nil {
+			return err
+		}
+		if err = json.Unmarshal(body, v); err != nil {
+			return fmt.Errorf("dragontail: invalid %s: %s", file, err)
+		}
+		if err = validate(); err != nil {
+			return fmt.Errorf("dragontail: invalid %s: %s", file, err)
+		}
+		return nil
+	}
+
+	var cachePath string
+	if len(d.cacheDir) > 0 {
+		cachePath = filepath.Join(d.cacheDir, version, locale, file)
+		if body, err := ioutil.ReadFile(cachePath); err == nil {
+			if err = json.Unmarshal(body, v); err == nil && validate() == nil {
+				d.fetcher.logger.Debug("data dragon cache hit", "file", cachePath)
+				return nil
+			}
+			d.fetcher.logger.Warn("data dragon cache invalid, refetching", "file", cachePath)
+			reflect.ValueOf(v).Elem().Set(reflect.Zero(reflect.TypeOf(v).Elem()))
+		}
+	}
+
+	url := DataDragonUrl + `/cdn/` + version + `/data/` + locale + `/` + file
+	body, err := d.fetcher.MakeRequest(ctx, url)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("data dragon: invalid %s: %s", url, err)
+	}
+	if err = validate(); err != nil {
+		return fmt.Errorf("data dragon: invalid %s: %s", url, err)
+	}
+
+	if len(cachePath) > 0 {
+		if err = writeFileAtomic(cachePath, body); err != nil {
+			d.fetcher.logger.Warn("write data dragon cache failed", "file", cachePath, "error", err)
+		}
+	}
+	return nil
+}
+
+// Versions returns all versions, newest first. When Data Dragon is not reachable,
+// the last cached list is used, so that cached patches work offline.
+func (d *DataDragon) Versions(ctx context.Context) ([]string, error) {
+	if d.dragontail != nil {
+		return d.dragontail.Versions(), nil
+	}
+
+	var versions []string
+	body, err := d.fetcher.MakeRequest(ctx, DataDragonUrl+"/api/versions.json")
+	if err == nil {
+		err = json.Unmarshal(body, &versions)
+	}
+	if err == nil && len(versions) == 0 {
+		err = errors.New("data dragon: empty version list")
+	}
+
+	var cachePath string
+	if len(d.cacheDir) > 0 {
+		cachePath = filepath.Join(d.cacheDir, "versions.json")
+	}
+
+	if err != nil {
+		if len(cachePath) == 0 {
+			return nil, err
+		}
+
+		body, cErr := ioutil.ReadFile(cachePath)
+		if cErr != nil || json.Unmarshal(body, &versions) != nil || len(versions) == 0 {
+			return nil, err
+		}
+		d.fetcher.logger.Warn("data dragon unreachable, using cached versions", "error", err)
+		return versions, nil
+	}
+
+	if len(cachePath) > 0 {
+		_ = writeFileAtomic(cachePath, body)
+	}
+	return versions, nil
+}
+
+// ResolveVersion returns `pinned` if it's not empty, otherwise the newest version whose files are available.
+// Right after a patch, `versions.json` may list a version before its files are uploaded,
+// in which case older versions are used.
+func (d *DataDragon) ResolveVersion(ctx context.Context, pinned string) (string, error) {
+	if len(pinned) > 0 {
+		return pinned, nil
+	}
+
+	versions, err := d.Versions(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	for i, version := range versions {
+		if i > MaxVersionFallback {
+			break
+		}
+
+		err = d.checkVersion(ctx, version)
+		if err == nil {
+			if i > 0 {
+				d.fetcher.logger.Warn("fell back to an older data dragon version", "version", version, "latest", versions[0])
+			}
+			return version, nil
+		}
+		if !isNotFound(err) {
+			return "", err
+		}
+		d.fetcher.logger.Warn("data dragon files not available", "version", version, "error", err)
+	}
+
+	return "", fmt.Errorf("data dragon: no available version in the latest %d", MaxVersionFallback+1)
+}
+
+// checkVersion makes sure all files needed by a crawl are available for `version`.
+func (d *DataDragon) checkVersion(ctx context.Context, version string) error {
+	if _, err := d.GetChampions(ctx, version, DefaultLocale); err != nil {
+		return err
+	}
+	if _, err := d.GetRunes(ctx, version, DefaultLocale); err != nil {
+		return err
+	}
+	if _, err := d.GetItems(ctx, version, DefaultLocale); err != nil {
+		return err
+	}
+	return nil
+}
+
+func isNotFound(err error) bool {
+	if os.IsNotExist(err) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		// missing files on the CDN are `403 Forbidden`
+		return httpErr.StatusCode == http.StatusNotFound || httpErr.StatusCode == http.StatusForbidden
+	}
+	return false
+}
+
+func (d *DataDragon) GetChampionList(ctx context.Context, version string) (*ChampionListResp, error) {
+	resp, err := d.GetChampions(ctx, version, DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	d.fetcher.logger.Info("got official champion list", "version", version, "count", len(resp.Data))
+	return resp, nil
+}
+
+func (d *DataDragon) GetChampions(ctx context.Context, version string, locale string) (*ChampionListResp, error) {
+	var resp ChampionListResp
+	err := d.getJSON(ctx, version, locale, "champion.json", &resp, func() error {
+		if len(resp.Data) == 0 {
+			return errors.New("no champion")
+		}
+		if resp.Version != version {
+			return fmt.Errorf("version mismatch, got %s", resp.Version)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (d *DataDragon) GetItemList(ctx context.Context, version string) (*map[string]BuildItem, error) {
+	resp, err := d.GetItems(ctx, version, DefaultLocale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp.Data, nil
+}
+
+func (d *DataDragon) GetItems(ctx context.Context, version string, locale string) (*BuildItemResp, error) {
+	var resp BuildItemResp
+	err := d.getJSON(ctx, version, locale, "item.json", &resp, func() error {
+		if len(resp.Data) == 0 {
+			return errors.New("no item")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func (d *DataDragon) GetRunesReforged(ctx context.Context, version string) (IRuneLookUp, IAllRunes, error) {
+	resp, err := d.GetRunes(ctx, version, DefaultLocale)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lookUp, allRunes := MakeRuneLookUp(resp)
+	return lookUp, allRunes, nil
+}
+
+func (d *DataDragon) GetRunes(ctx context.Context, version string, locale string) ([]RuneSlot, error) {
+	var resp []RuneSlot
+	err := d.getJSON(ctx, version, locale, "runesReforged.json", &resp, func() error {
+		if len(resp) == 0 {
+			return errors.New("no rune")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (d *DataDragon) GetSummoners(ctx context.Context, version string, locale string) (*SummonerSpellResp, error) {
+	var resp SummonerSpellResp
+	err := d.getJSON(ctx, version, locale, "summoner.json", &resp, func() error {
+		if len(resp.Data) == 0 {
+			return errors.New("no summoner spell")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// MakeRuneLookUp indexes all runes by id, with their style & slot filled.
+func MakeRuneLookUp(slots []RuneSlot) (IRuneLookUp, IAllRunes) {
+	data := make(map[int]*RespRuneItem)
+	for _, slot := range slots {
+		for j, s := range slot.Slots {
+			for _, r := range s.Runes {
+				r := r
+				r.Style = slot.Id
+				r.Slot = j
+				r.Primary = j == 0
+				data[r.Id] = &r
+			}
+		}
+	}
+
+	return data, &slots
+}
+
+func writeFileAtomic(path string, body []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.Write(body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,281 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DiffAdded   = `added`
+	DiffRemoved = `removed`
+	DiffChanged = `changed`
+)
+
+// Package is a generated package loaded from disk.
+type Package struct {
+	Info PkgInfo
+	// Champions are the champion files, keyed by alias.
+	Champions map[string][]ChampionDataItem
+}
+
+// LoadPackage reads `package.json` & champion files of the package in `dir`.
+func LoadPackage(dir string) (*Package, error) {
+	var pkg Package
+
+	body, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &pkg.Info); err != nil {
+		return nil, fmt.Errorf("%s: %s", dir, err)
+	}
+	// `package.json` only has the npm name, e.g. `@champ-r/op.gg`
+	pkg.Info.PkgName = filepath.Base(dir)
+
+	pkg.Champions, err = loadChampionFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &pkg, nil
+}
+
+// loadChampionFiles reads all `<alias>.json` of the package in `dir`, keyed by alias.
+func loadChampionFiles(dir string) (map[string][]ChampionDataItem, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]ChampionDataItem)
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name == "package.json" || name == SchemaFile || name == IndexFile {
+			continue
+		}
+
+		var items []ChampionDataItem
+		body, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = json.Unmarshal(body, &items)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", file, err)
+		}
+		result[strings.TrimSuffix(name, ".json")] = items
+	}
+
+	return result, nil
+}
+
+// WinRateShift is the change of the win rate of a rune page found in both packages.
+type WinRateShift struct {
+	Page string  `json:"page"`
+	Old  float64 `json:"old"`
+	New  float64 `json:"new"`
+}
+
+// ChampionDiff lists what changed for a champion at a position.
+type ChampionDiff struct {
+	Champion     string         `json:"champion"`
+	Position     string         `json:"position,omitempty"`
+	Status       string         `json:"status"`
+	AddedRunes   []string       `json:"addedRunes,omitempty"`
+	RemovedRunes []string       `json:"removedRunes,omitempty"`
+	Blocks       []string       `json:"blocks,omitempty"`
+	WinRates     []WinRateShift `json:"winRates,omitempty"`
+}
+
+type PackageDiff struct {
+	PkgName    string         `json:"pkgName"`
+	OldVersion string         `json:"oldVersion"`
+	NewVersion string         `json:"newVersion"`
+	Champions  []ChampionDiff `json:"champions"`
+}
+
+// DiffPackages compares two versions of a package, win rate shifts smaller than `minShift`
+// percentage points are ignored.
+func DiffPackages(old *Package, cur *Package, minShift float64) *PackageDiff {
+	d := PackageDiff{
+		PkgName:    old.Info.PkgName,
+		OldVersion: old.Info.SourceVersion,
+		NewVersion: cur.Info.SourceVersion,
+		Champions:  []ChampionDiff{},
+	}
+	if len(cur.Info.PkgName) > 0 {
+		d.PkgName = cur.Info.PkgName
+	}
+
+	oldItems := indexByPosition(old)
+	newItems := indexByPosition(cur)
+	keys := make(map[string]bool)
+	for k := range oldItems {
+		keys[k] = true
+	}
+	for k := range newItems {
+		keys[k] = true
+	}
+
+	for _, k := range sortedKeys(keys) {
+		o, inOld := oldItems[k]
+		n, inNew := newItems[k]
+		switch {
+		case !inOld:
+			d.Champions = append(d.Champions, ChampionDiff{Champion: n.Alias, Position: n.Position, Status: DiffAdded})
+		case !inNew:
+			d.Champions = append(d.Champions, ChampionDiff{Champion: o.Alias, Position: o.Position, Status: DiffRemoved})
+		default:
+			if c := diffChampion(o, n, minShift); c != nil {
+				d.Champions = append(d.Champions, *c)
+			}
+		}
+	}
+
+	return &d
+}
+
+func indexByPosition(pkg *Package) map[string]ChampionDataItem {
+	result := make(map[string]ChampionDataItem)
+	for alias, items := range pkg.Champions {
+		for _, item := range items {
+			result[alias+"@"+item.Position] = item
+		}
+	}
+	return result
+}
+
+func diffChampion(old ChampionDataItem, cur ChampionDataItem, minShift float64) *ChampionDiff {
+	c := ChampionDiff{
+		Champion: cur.Alias,
+		Position: cur.Position,
+		Status:   DiffChanged,
+	}
+
+	oldPages := indexRunePages(old.Runes)
+	newPages := indexRunePages(cur.Runes)
+	for _, k := range sortedKeys(newPages) {
+		if _, ok := oldPages[k]; !ok {
+			c.AddedRunes = append(c.AddedRunes, k)
+		}
+	}
+	for _, k := range sortedKeys(oldPages) {
+		o := oldPages[k]
+		n, ok := newPages[k]
+		if !ok {
+			c.RemovedRunes = append(c.RemovedRunes, k)
+			continue
+		}
+
+		ow, oErr := parseWinRate(o.WinRate)
+		nw, nErr := parseWinRate(n.WinRate)
+		if oErr == nil && nErr == nil && math.Abs(nw-ow) >= minShift && nw != ow {
+			c.WinRates = append(c.WinRates, WinRateShift{Page: k, Old: ow, New: nw})
+		}
+	}
+
+	for i := 0; i < len(old.ItemBuilds) || i < len(cur.ItemBuilds); i++ {
+		var oldBlocks, newBlocks []ItemBuildBlockItem
+		if i < len(old.ItemBuilds) {
+			oldBlocks = old.ItemBuilds[i].Blocks
+		}
+		if i < len(cur.ItemBuilds) {
+			newBlocks = cur.ItemBuilds[i].Blocks
+		}
+
+		for j := 0; j < len(oldBlocks) || j < len(newBlocks); j++ {
+			var o, n string
+			var title string
+			if j < len(oldBlocks) {
+				o = blockIds(oldBlocks[j])
+				title = oldBlocks[j].Type
+			}
+			if j < len(newBlocks) {
+				n = blockIds(newBlocks[j])
+				title = newBlocks[j].Type
+			}
+			if o != n {
+				c.Blocks = append(c.Blocks, fmt.Sprintf("build %d block %d (%s): [%s] -> [%s]", i+1, j+1, title, o, n))
+			}
+		}
+	}
+
+	if len(c.AddedRunes) == 0 && len(c.RemovedRunes) == 0 && len(c.Blocks) == 0 && len(c.WinRates) == 0 {
+		return nil
+	}
+	return &c
+}
+
+// indexRunePages keys rune pages by their perk ids, as names may contain win rates & pick counts.
+func indexRunePages(pages []RuneItem) map[string]RuneItem {
+	result := make(map[string]RuneItem)
+	for _, p := range pages {
+		ids := make([]string, len(p.SelectedPerkIds))
+		for i, id := range p.SelectedPerkIds {
+			ids[i] = strconv.Itoa(id)
+		}
+		result[strings.Join(ids, ",")] = p
+	}
+	return result
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := GetKeys(m)
+	sort.Strings(keys)
+	return keys
+}
+
+func blockIds(block ItemBuildBlockItem) string {
+	ids := make([]string, len(block.Items))
+	for i, item := range block.Items {
+		ids[i] = item.Id
+	}
+	return strings.Join(ids, ",")
+}
+
+// parseWinRate reads win rates like `52.3%`.
+func parseWinRate(s string) (float64, error) {
+	return strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(s), "%")), 64)
+}
+
+func (d *PackageDiff) Empty() bool {
+	return len(d.Champions) == 0
+}
+
+// String formats the diff for humans, `+` for added, `-` for removed & `~` for changed.
+func (d *PackageDiff) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (%s -> %s)\n", d.PkgName, d.OldVersion, d.NewVersion)
+	for _, c := range d.Champions {
+		name := c.Champion
+		if len(c.Position) > 0 {
+			name += "@" + c.Position
+		}
+
+		switch c.Status {
+		case DiffAdded:
+			fmt.Fprintf(&b, "  + %s\n", name)
+		case DiffRemoved:
+			fmt.Fprintf(&b, "  - %s\n", name)
+		default:
+			fmt.Fprintf(&b, "  ~ %s\n", name)
+			for _, r := range c.AddedRunes {
+				fmt.Fprintf(&b, "      + rune page [%s]\n", r)
+			}
+			for _, r := range c.RemovedRunes {
+				fmt.Fprintf(&b, "      - rune page [%s]\n", r)
+			}
+			for _, s := range c.Blocks {
+				fmt.Fprintf(&b, "      ~ %s\n", s)
+			}
+			for _, w := range c.WinRates {
+				fmt.Fprintf(&b, "      ~ win rate [%s]: %.2f%% -> %.2f%% (%+.2f)\n", w.Page, w.Old, w.New, w.New-w.Old)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,122 @@
+package common
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testChampion(alias, position string, perks []int, winRate string, items ...string) ChampionDataItem {
+	block := ItemBuildBlockItem{Type: "Core Items"}
+	for _, id := range items {
+		block.Items = append(block.Items, BlockItem{Id: id, Count: 1})
+	}
+	return ChampionDataItem{
+		Alias:      alias,
+		Position:   position,
+		Runes:      []RuneItem{{Alias: alias, Position: position, WinRate: winRate, SelectedPerkIds: perks}},
+		ItemBuilds: []ItemBuild{{Blocks: []ItemBuildBlockItem{block}}},
+	}
+}
+
+func testPackage(version string, items ...ChampionDataItem) *Package {
+	pkg := Package{
+		Info:      PkgInfo{PkgName: "op.gg", SourceVersion: version},
+		Champions: map[string][]ChampionDataItem{},
+	}
+	for _, item := range items {
+		pkg.Champions[item.Alias] = append(pkg.Champions[item.Alias], item)
+	}
+	return &pkg
+}
+
+func TestDiffPackages(t *testing.T) {
+	zed := testChampion("Zed", "mid", []int{8112, 8143}, "51.0%", "3142", "6692")
+	old := testPackage("11.1", zed, testChampion("Yone", "top", []int{8010}, "49%", "3031"))
+
+	tests := []struct {
+		name     string
+		cur      *Package
+		minShift float64
+		expected []ChampionDiff
+	}{
+		{name: "unchanged", cur: testPackage("11.2", zed, testChampion("Yone", "top", []int{8010}, "49%", "3031"))},
+		{
+			name: "added & removed",
+			cur:  testPackage("11.2", zed, testChampion("Yone", "mid", []int{8010}, "49%", "3031")),
+			expected: []ChampionDiff{
+				{Champion: "Yone", Position: "mid", Status: DiffAdded},
+				{Champion: "Yone", Position: "top", Status: DiffRemoved},
+			},
+		},
+		{
+			name: "changed build",
+			cur:  testPackage("11.2", zed, testChampion("Yone", "top", []int{8010}, "49%", "3031", "3046")),
+			expected: []ChampionDiff{
+				{Champion: "Yone", Position: "top", Status: DiffChanged, Blocks: []string{"build 1 block 1 (Core Items): [3031] -> [3031,3046]"}},
+			},
+		},
+		{
+			name: "changed runes",
+			cur:  testPackage("11.2", zed, testChampion("Yone", "top", []int{8021}, "49%", "3031")),
+			expected: []ChampionDiff{
+				{Champion: "Yone", Position: "top", Status: DiffChanged, AddedRunes: []string{"8021"}, RemovedRunes: []string{"8010"}},
+			},
+		},
+		{
+			name:     "win rate shift",
+			cur:      testPackage("11.2", zed, testChampion("Yone", "top", []int{8010}, "51.5%", "3031")),
+			minShift: 1,
+			expected: []ChampionDiff{
+				{Champion: "Yone", Position: "top", Status: DiffChanged, WinRates: []WinRateShift{{Page: "8010", Old: 49, New: 51.5}}},
+			},
+		},
+		{
+			name:     "win rate shift below threshold",
+			cur:      testPackage("11.2", zed, testChampion("Yone", "top", []int{8010}, "49.5%", "3031")),
+			minShift: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := DiffPackages(old, tt.cur, tt.minShift)
+			if d.PkgName != "op.gg" || d.OldVersion != "11.1" || d.NewVersion != "11.2" {
+				t.Fatalf("diff of %s %s -> %s", d.PkgName, d.OldVersion, d.NewVersion)
+			}
+			if len(tt.expected) == 0 {
+				if !d.Empty() {
+					t.Fatalf("expected no changes, got %+v", d.Champions)
+				}
+				return
+			}
+			if !reflect.DeepEqual(d.Champions, tt.expected) {
+				t.Fatalf("changes %+v, expected %+v", d.Champions, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPackageDiff_String(t *testing.T) {
+	d := PackageDiff{
+		PkgName:    "op.gg",
+		OldVersion: "11.1",
+		NewVersion: "11.2",
+		Champions: []ChampionDiff{
+			{Champion: "Yone", Position: "mid", Status: DiffAdded},
+			{Champion: "Yone", Position: "top", Status: DiffRemoved},
+			{Champion: "Zed", Position: "mid", Status: DiffChanged, WinRates: []WinRateShift{{Page: "8112", Old: 49, New: 51.5}}},
+		},
+	}
+
+	expected := []string{
+		"op.gg (11.1 -> 11.2)",
+		"  + Yone@mid",
+		"  - Yone@top",
+		"  ~ Zed@mid",
+		"      ~ win rate [8112]: 49.00% -> 51.50% (+2.50)",
+	}
+	if got := d.String(); got != strings.Join(expected, "\n")+"\n" {
+		t.Fatalf("got\n%s", got)
+	}
+}
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dragontailFiles are the only files read from a dragontail, others are skipped when scanning an archive.
+var dragontailFiles = []string{"champion.json", "item.json", "runesReforged.json", "summoner.json"}
+
+// Dragontail reads Data Dragon files from a `dragontail-<version>` bundle, either extracted
+// or as the `.tgz` itself, files are found at `<version>/data/<locale>/<file>`.
+type Dragontail struct {
+	dir      string
+	files    map[string][]byte
+	versions []string
+}
+
+// OpenDragontail opens an extracted dragontail directory, or loads the needed files of a `.tgz` into memory.
+func OpenDragontail(p string) (*Dragontail, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+
+	var t *Dragontail
+	if info.IsDir() {
+		t, err = openDragontailDir(p)
+	} else {
+		t, err = openDragontailArchive(p)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(t.versions) == 0 {
+		return nil, fmt.Errorf("dragontail: no version found in %s", p)
+	}
+
+	sort.Slice(t.versions, func(i, j int) bool {
+		return compareVersions(t.versions[i], t.versions[j]) > 0
+	})
+	return t, nil
+}
+
+func openDragontailDir(dir string) (*Dragontail, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Dragontail{dir: dir}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if info, err := os.Stat(filepath.Join(dir, e.Name(), "data")); err == nil && info.IsDir() {
+			t.versions = append(t.versions, e.Name())
+		}
+	}
+	return t, nil
+}
+
+func openDragontailArchive(p string) (*Dragontail, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("dragontail: %s: %s", p, err)
+	}
+	defer gz.Close()
+
+	t := &Dragontail{files: make(map[string][]byte)}
+	found := make(map[string]bool)
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("dragontail: %s: %s", p, err)
+		}
+		if h.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		// entries may be prefixed by `./` or a top level folder
+		parts := strings.Split(path.Clean(h.Name), "/")
+		if len(parts) < 4 {
+			continue
+		}
+		parts = parts[len(parts)-4:]
+		if parts[1] != "data" || !Includes(parts[3], dragontailFiles) {
+			continue
+		}
+
+		body, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("dragontail: %s: %s", p, err)
+		}
+		t.files[strings.Join(parts, "/")] = body
+		if !found[parts[0]] {
+			found[parts[0]] = true
+			t.versions = append(t.versions, parts[0])
+		}
+	}
+	return t, nil
+}
+
+// Versions returns the versions in the bundle, newest first.
+func (t *Dragontail) Versions() []string {
+	return t.versions
+}
+
+func (t *Dragontail) ReadFile(version string, locale string, file string) ([]byte, error) {
+	if t.files == nil {
+		return ioutil.ReadFile(filepath.Join(t.dir, version, "data", locale, file))
+	}
+
+	name := version + "/data/" + locale + "/" + file
+	body, ok := t.files[name]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return body, nil
+}
+
+// compareVersions compares dotted versions like `11.14.1` numerically.
+func compareVersions(a string, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, xErr := strconv.Atoi(as[i])
+		y, yErr := strconv.Atoi(bs[i])
+		if xErr != nil || yErr != nil {
+			if c := strings.Compare(as[i], bs[i]); c != 0 {
+				return c
+			}
+			continue
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -0,0 +1,252 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/PuerkitoBio/goquery"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+const DefaultTimeout = 30 * time.Second
+
+type FetcherOptions struct {
+	// Timeout is the deadline of a single attempt, defaults to `DefaultTimeout`.
+	Timeout time.Duration
+	// UserAgent overrides the default Go user agent when not empty.
+	UserAgent string
+	// Proxy is the proxy url, e.g. `http://127.0.0.1:7890`.
+	// It's ignored when `Transport` is provided.
+	Proxy string
+	// Transport replaces `http.DefaultTransport`, e.g. a test or caching transport.
+	Transport http.RoundTripper
+	// RecordDir saves all responses into a cassette directory.
+	RecordDir string
+	// ReplayDir serves all responses from a cassette directory, without network access.
+	ReplayDir string
+	// RateLimit is the allowed requests per second for each host, zero means unlimited.
+	// It's disabled in replay mode.
+	RateLimit float64
+	// RateBurst is the maximum requests to a host at once, defaults to 1.
+	RateBurst int
+	// Retry is the default retry policy, it's disabled in replay mode.
+	Retry RetryPolicy
+	// SourceRetry overrides `Retry` for sources, keyed by source name or package name.
+	SourceRetry map[string]RetryPolicy
+	// Logger defaults to `Log`.
+	Logger *Logger
+}
+
+// FetchStats counts requests sent by a fetcher, failures are requests given up after all attempts.
+type FetchStats struct {
+	Requests int64 `json:"requests"`
+	Retries  int64 `json:"retries"`
+	Failures int64 `json:"failures"`
+}
+
+// Add returns the sum of `s` & `o`.
+func (s FetchStats) Add(o FetchStats) FetchStats {
+	return FetchStats{
+		Requests: s.Requests + o.Requests,
+		Retries:  s.Retries + o.Retries,
+		Failures: s.Failures + o.Failures,
+	}
+}
+
+// Fetcher is the only way to reach upstream services,
+// it's shared by all sources & Data Dragon helpers.
+type Fetcher struct {
+	client      *http.Client
+	timeout     time.Duration
+	userAgent   string
+	limiter     *RateLimiter
+	retry       RetryPolicy
+	sourceRetry map[string]RetryPolicy
+	stats       *FetchStats
+	logger      *Logger
+}
+
+func NewFetcher(opts FetcherOptions) (*Fetcher, error) {
+	if len(opts.RecordDir) > 0 && len(opts.ReplayDir) > 0 {
+		return nil, errors.New("fetcher: record & replay can't be used together")
+	}
+
+	transport := opts.Transport
+	if transport == nil && len(opts.Proxy) > 0 {
+		proxyUrl, err := url.Parse(opts.Proxy)
+		if err != nil {
+			return nil, err
+		}
+
+		t := http.DefaultTransport.(*http.Transport).Clone()
+		t.Proxy = http.ProxyURL(proxyUrl)
+		transport = t
+	}
+
+	if len(opts.RecordDir) > 0 {
+		t, err := NewRecordTransport(opts.RecordDir, transport)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+	if len(opts.ReplayDir) > 0 {
+		t, err := NewReplayTransport(opts.ReplayDir)
+		if err != nil {
+			return nil, err
+		}
+		transport = t
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	f := Fetcher{
+		client: &http.Client{
+			Transport: transport,
+		},
+		timeout:     timeout,
+		userAgent:   opts.UserAgent,
+		retry:       opts.Retry,
+		sourceRetry: opts.SourceRetry,
+		stats:       &FetchStats{},
+		logger:      opts.Logger,
+	}
+	if f.logger == nil {
+		f.logger = Log
+	}
+	if opts.RateLimit > 0 && len(opts.ReplayDir) == 0 {
+		f.limiter = NewRateLimiter(opts.RateLimit, opts.RateBurst)
+	}
+	if len(opts.ReplayDir) > 0 {
+		f.retry = RetryPolicy{Attempts: 1}
+		f.sourceRetry = nil
+	}
+
+	return &f, nil
+}
+
+// ForSource returns a fetcher sharing the connections & rate limiter with `f`,
+// but with the retry policy of source `s` and its own stats.
+func (f *Fetcher) ForSource(s Source) *Fetcher {
+	c := *f
+	c.stats = &FetchStats{}
+	c.logger = f.logger.With("source", s.PkgName())
+	if p, ok := f.sourceRetry[s.PkgName()]; ok {
+		c.retry = p
+	} else if p, ok := f.sourceRetry[s.Name()]; ok {
+		c.retry = p
+	}
+
+	return &c
+}
+
+func (f *Fetcher) Stats() FetchStats {
+	return FetchStats{
+		Requests: atomic.LoadInt64(&f.stats.Requests),
+		Retries:  atomic.LoadInt64(&f.stats.Retries),
+		Failures: atomic.LoadInt64(&f.stats.Failures),
+	}
+}
+
+func (f *Fetcher) MakeRequest(ctx context.Context, url string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		body, retryable, err := f.send(ctx, url)
+		if err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil {
+			atomic.AddInt64(&f.stats.Failures, 1)
+			return nil, cancelledError(ctx, err)
+		}
+		if !retryable || attempt >= f.retry.Attempts {
+			atomic.AddInt64(&f.stats.Failures, 1)
+			f.logger.Debug("request failed", "url", url, "attempt", attempt, "error", err)
+			return nil, err
+		}
+
+		var retryAfter time.Duration
+		if httpErr, ok := err.(*HTTPError); ok {
+			retryAfter = httpErr.RetryAfter
+		}
+		delay := f.retry.delay(attempt, retryAfter)
+		f.logger.Warn("request failed, retrying", "url", url, "attempt", attempt, "delay", delay, "error", err)
+
+		atomic.AddInt64(&f.stats.Retries, 1)
+		if sErr := Sleep(ctx, delay); sErr != nil {
+			atomic.AddInt64(&f.stats.Failures, 1)
+			return nil, cancelledError(ctx, err)
+		}
+	}
+}
+
+// cancelledError wraps the error of `ctx`, so that callers can tell a cancelled request from
+// a failed one, while keeping `err` of the last attempt.
+func cancelledError(ctx context.Context, err error) error {
+	if errors.Is(err, ctx.Err()) {
+		return err
+	}
+	return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+}
+
+// send makes a single attempt, and tells whether it's worth retrying when failed.
+func (f *Fetcher) send(ctx context.Context, url string) ([]byte, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(f.userAgent) > 0 {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	// time queued behind the rate limiter doesn't count against the attempt timeout
+	if err = f.limiter.Wait(ctx, req.URL.Host); err != nil {
+		return nil, false, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, f.timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+	atomic.AddInt64(&f.stats.Requests, 1)
+	start := time.Now()
+	res, err := f.client.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	f.logger.Debug("request", "url", url, "status", res.StatusCode, "duration", time.Since(start))
+
+	defer res.Body.Close()
+	if res.StatusCode != 200 {
+		httpErr := HTTPError{
+			Url:        url,
+			StatusCode: res.StatusCode,
+			Status:     res.Status,
+		}
+		if res.StatusCode == http.StatusTooManyRequests || res.StatusCode == http.StatusServiceUnavailable {
+			httpErr.RetryAfter = parseRetryAfter(res.Header.Get("Retry-After"))
+		}
+		return nil, isRetryable(res.StatusCode), &httpErr
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, true, err
+	}
+	return body, false, nil
+}
+
+func (f *Fetcher) ParseHTML(ctx context.Context, url string) (*goquery.Document, error) {
+	body, err := f.MakeRequest(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return goquery.NewDocumentFromReader(bytes.NewReader(body))
+}
@@ -0,0 +1,35 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFetcher_MakeRequest_cancelledDuringBackoff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	f, err := NewFetcher(FetcherOptions{
+		Retry: RetryPolicy{Attempts: 3, BaseDelay: time.Minute, MaxDelay: time.Minute},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = f.MakeRequest(ctx, srv.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("error %v, expected the context error", err)
+	}
+	if !strings.Contains(err.Error(), "500") {
+		t.Fatalf("error %v, expected the last error", err)
+	}
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const DefaultPublishStateFile = `.cache/publish-state.json`
+
+// ContentHash is the sha256 of the package content, without fields changing on every crawl
+// (`Timestamp` & `Index`), so that a package is only published when its data changes.
+func ContentHash(info PkgInfo, champions map[string][]ChampionDataItem) string {
+	h := sha256.New()
+
+	versions, _ := json.Marshal([]interface{}{info.SourceVersion, info.OfficialVersion, info.DataDragonVersion, info.SchemaVersion})
+	h.Write(versions)
+
+	aliases := GetKeys(champions)
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		items := make([]ChampionDataItem, len(champions[alias]))
+		copy(items, champions[alias])
+		for i := range items {
+			items[i].Timestamp = 0
+			items[i].Index = 0
+		}
+
+		body, _ := json.Marshal(items)
+		h.Write([]byte("\n" + alias + "\n"))
+		h.Write(body)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type PublishedPackage struct {
+	ContentHash string    `json:"contentHash"`
+	PublishedAt time.Time `json:"publishedAt"`
+}
+
+// PublishState keeps the content hash of the last published version of each package.
+type PublishState struct {
+	path     string
+	Packages map[string]PublishedPackage `json:"packages"`
+}
+
+// LoadPublishState reads the state at `path`, a missing file is an empty state.
+func LoadPublishState(path string) (*PublishState, error) {
+	s := PublishState{
+		path:     path,
+		Packages: make(map[string]PublishedPackage),
+	}
+
+	body, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &s); err != nil {
+		return nil, err
+	}
+	if s.Packages == nil {
+		s.Packages = make(map[string]PublishedPackage)
+	}
+	return &s, nil
+}
+
+// Unchanged reports whether `hash` is the content hash of the last published `pkgName`.
+func (s *PublishState) Unchanged(pkgName string, hash string) bool {
+	p, ok := s.Packages[pkgName]
+	return ok && len(hash) > 0 && p.ContentHash == hash
+}
+
+func (s *PublishState) Set(pkgName string, hash string) {
+	s.Packages[pkgName] = PublishedPackage{
+		ContentHash: hash,
+		PublishedAt: time.Now(),
+	}
+}
+
+func (s *PublishState) Save() error {
+	body, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Clean(s.path), body)
+}
@@ -0,0 +1,93 @@
+package common
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestContentHash(t *testing.T) {
+	info := PkgInfo{PkgName: "op.gg", Timestamp: 1, SourceVersion: "11.1", OfficialVersion: "11.1.1", SchemaVersion: 1}
+	champions := func(update func(item *ChampionDataItem)) map[string][]ChampionDataItem {
+		zed := testChampion("Zed", "mid", []int{8112}, "51%", "3142")
+		zed.Index, zed.Timestamp = 1, 1
+		update(&zed)
+		return map[string][]ChampionDataItem{
+			"Zed":  {zed},
+			"Yone": {testChampion("Yone", "top", []int{8010}, "49%", "3031")},
+		}
+	}
+	base := ContentHash(info, champions(func(item *ChampionDataItem) {}))
+
+	tests := []struct {
+		name    string
+		info    func(info PkgInfo) PkgInfo
+		item    func(item *ChampionDataItem)
+		changed bool
+	}{
+		{name: "timestamp", item: func(item *ChampionDataItem) { item.Timestamp = 2 }},
+		{name: "index", item: func(item *ChampionDataItem) { item.Index = 7 }},
+		{name: "package timestamp", info: func(info PkgInfo) PkgInfo { info.Timestamp = 2; return info }},
+		{name: "source version", info: func(info PkgInfo) PkgInfo { info.SourceVersion = "11.2"; return info }, changed: true},
+		{name: "schema version", info: func(info PkgInfo) PkgInfo { info.SchemaVersion = 2; return info }, changed: true},
+		{name: "win rate", item: func(item *ChampionDataItem) { item.Runes[0].WinRate = "52%" }, changed: true},
+		{name: "position", item: func(item *ChampionDataItem) { item.Position = "jungle" }, changed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := info
+			if tt.info != nil {
+				i = tt.info(i)
+			}
+			item := tt.item
+			if item == nil {
+				item = func(item *ChampionDataItem) {}
+			}
+			if got := ContentHash(i, champions(item)); (got != base) != tt.changed {
+				t.Fatalf("hash changed: %t, expected %t", got != base, tt.changed)
+			}
+		})
+	}
+}
+
+func TestPublishState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "publish")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "publish-state.json")
+
+	s, err := LoadPublishState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Unchanged("op.gg", "abc") {
+		t.Fatal("expected an empty state without a file")
+	}
+	s.Set("op.gg", "abc")
+	if err = s.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = LoadPublishState(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		pkgName, hash string
+		unchanged     bool
+	}{
+		{pkgName: "op.gg", hash: "abc", unchanged: true},
+		{pkgName: "op.gg", hash: "def"},
+		{pkgName: "op.gg", hash: ""},
+		{pkgName: "lolalytics", hash: "abc"},
+	}
+	for _, tt := range tests {
+		if got := s.Unchanged(tt.pkgName, tt.hash); got != tt.unchanged {
+			t.Errorf("%s %q: unchanged %t, expected %t", tt.pkgName, tt.hash, got, tt.unchanged)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+const IndexFile = `index.json`
+
+// IndexItem describes a champion file of a package, it keeps `id`, `key` & `name` of the Data Dragon champion list.
+type IndexItem struct {
+	Id        string            `json:"id"`
+	Key       string            `json:"key"`
+	Name      string            `json:"name"`
+	Names     map[string]string `json:"names,omitempty"`
+	Titles    map[string]string `json:"titles,omitempty"`
+	Positions []string          `json:"positions"`
+	File      string            `json:"file"`
+}
+
+// MakeIndex lists champions found in `files`, keyed by alias, with the positions they have data for.
+func MakeIndex(files map[string][]ChampionDataItem, champions map[string]ChampionItem) map[string]IndexItem {
+	index := make(map[string]IndexItem)
+	for alias, items := range files {
+		c := champions[alias]
+		item := IndexItem{
+			Id:        alias,
+			Key:       c.Key,
+			Name:      c.Name,
+			Names:     c.Names,
+			Titles:    c.Titles,
+			Positions: []string{},
+			File:      alias + ".json",
+		}
+		if len(item.Name) == 0 && len(items) > 0 {
+			item.Name = items[0].Name
+		}
+
+		for _, i := range items {
+			if len(i.Position) > 0 && !Includes(i.Position, item.Positions) {
+				item.Positions = append(item.Positions, i.Position)
+			}
+		}
+		sort.Strings(item.Positions)
+
+		index[alias] = item
+	}
+
+	return index
+}
+
+// WriteIndex writes `index.json` of the package in `dir` from its champion files.
+// It's expected to run once all sources have finished, so that no source writes it concurrently.
+func WriteIndex(dir string, champions map[string]ChampionItem) error {
+	files, err := loadChampionFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(MakeIndex(files, champions), "", "  ")
+	if err != nil {
+		return fmt.Errorf("%s: %s", dir, err)
+	}
+	return writeFileAtomic(filepath.Join(dir, IndexFile), body)
+}
@@ -0,0 +1,66 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ItemValidator checks that items of a build exist in `item.json` of the official version,
+// can be bought, and are available on a map the build is associated with, e.g. a lane build
+// for Summoner's Rift & Howling Abyss keeps Control Wards, which only exist on Summoner's Rift.
+type ItemValidator struct {
+	items map[string]BuildItem
+}
+
+func NewItemValidator(items *map[string]BuildItem) *ItemValidator {
+	v := ItemValidator{}
+	if items != nil {
+		v.items = *items
+	}
+
+	return &v
+}
+
+func (v *ItemValidator) Validate(id string, maps []int) error {
+	item, ok := v.items[id]
+	if !ok {
+		return errors.New("unknown item")
+	}
+	if !item.Gold.Purchasable {
+		return errors.New("not purchasable")
+	}
+	for _, m := range maps {
+		if item.Maps[strconv.Itoa(m)] {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("not available on maps %v", maps)
+}
+
+// DropInvalid removes invalid items from builds of `item`, and records why in `result`.
+// Blocks left empty are removed too.
+func (v *ItemValidator) DropInvalid(result *Result, item *ChampionDataItem) {
+	for i := range item.ItemBuilds {
+		build := &item.ItemBuilds[i]
+		blocks := build.Blocks[:0]
+		for _, block := range build.Blocks {
+			items := block.Items[:0]
+			for _, it := range block.Items {
+				if err := v.Validate(it.Id, build.AssociatedMaps); err != nil {
+					result.Reject(item.Alias, item.Position, "item "+it.Id+" in "+block.Type+": "+err.Error())
+					continue
+				}
+				items = append(items, it)
+			}
+			if len(items) == 0 {
+				continue
+			}
+
+			block.Items = items
+			blocks = append(blocks, block)
+		}
+		build.Blocks = blocks
+	}
+}
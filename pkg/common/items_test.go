@@ -0,0 +1,113 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+// testItems is a subset of `item.json`, with the purchasability & maps of real data.
+func testItems() *map[string]BuildItem {
+	item := func(name string, purchasable bool, maps ...string) BuildItem {
+		i := BuildItem{Name: name, Gold: ItemGold{Purchasable: purchasable}, Maps: map[string]bool{"11": false, "12": false}}
+		for _, m := range maps {
+			i.Maps[m] = true
+		}
+		return i
+	}
+
+	return &map[string]BuildItem{
+		"1001": item("Boots", true, "11", "12"),
+		"1055": item("Doran's Blade", true, "11", "12"),
+		"2003": item("Health Potion", true, "11", "12"),
+		"2055": item("Control Ward", true, "11"),
+		"3340": item("Stealth Ward", true, "11"),
+		"3363": item("Farsight Alteration", true, "11"),
+		"3364": item("Oracle Lens", true, "11"),
+		"3040": item("Seraph's Embrace", false, "11", "12"),
+		"3112": item("Guardian's Orb", true, "12"),
+	}
+}
+
+func TestItemValidator_Validate(t *testing.T) {
+	v := NewItemValidator(testItems())
+
+	tests := []struct {
+		name string
+		id   string
+		maps []int
+		err  string
+	}{
+		{name: "classic", id: "1001", maps: []int{11, 12}},
+		{name: "aram", id: "1001", maps: []int{12}},
+		{name: "control ward in a lane build", id: "2055", maps: []int{11, 12}},
+		{name: "stealth ward in a lane build", id: "3340", maps: []int{11, 12}},
+		{name: "farsight alteration in a lane build", id: "3363", maps: []int{11, 12}},
+		{name: "oracle lens in a lane build", id: "3364", maps: []int{11, 12}},
+		{name: "aram only item in a lane build", id: "3112", maps: []int{11, 12}},
+		{name: "control ward in an aram build", id: "2055", maps: []int{12}, err: "not available on maps [12]"},
+		{name: "aram only item on summoner's rift", id: "3112", maps: []int{11}, err: "not available on maps [11]"},
+		{name: "not purchasable", id: "3040", maps: []int{11}, err: "not purchasable"},
+		{name: "unknown", id: "3041", maps: []int{11}, err: "unknown item"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(tt.id, tt.maps)
+			if len(tt.err) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error %v, expected %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestItemValidator_DropInvalid(t *testing.T) {
+	v := NewItemValidator(testItems())
+	block := func(typ string, ids ...string) ItemBuildBlockItem {
+		b := ItemBuildBlockItem{Type: typ}
+		for _, id := range ids {
+			b.Items = append(b.Items, BlockItem{Id: id, Count: 1})
+		}
+		return b
+	}
+
+	// a lane build keeps vision items, only available on summoner's rift
+	item := ChampionDataItem{
+		Alias:    "Aatrox",
+		Position: "top",
+		ItemBuilds: []ItemBuild{
+			{
+				AssociatedMaps: []int{11, 12},
+				Blocks: []ItemBuildBlockItem{
+					block("Starter Items", "1055", "2003", "3340"),
+					block("Trinkets", "3363", "3364"),
+					block("Consumables", "2055"),
+					block("Removed", "3041"),
+				},
+			},
+		},
+	}
+
+	result := NewResult("test")
+	v.DropInvalid(result, &item)
+	var kept []string
+	for _, b := range item.ItemBuilds[0].Blocks {
+		for _, i := range b.Items {
+			kept = append(kept, i.Id)
+		}
+	}
+	if got := strings.Join(kept, ","); got != "1055,2003,3340,3363,3364,2055" {
+		t.Fatalf("items %s, expected all but the removed one", got)
+	}
+	if len(item.ItemBuilds[0].Blocks) != 3 {
+		t.Fatalf("%d blocks, expected the empty block to be removed", len(item.ItemBuilds[0].Blocks))
+	}
+	if len(result.Rejected) != 1 || !strings.Contains(result.Rejected[0].Reason, "item 3041") {
+		t.Fatalf("rejected %+v, expected the removed item", result.Rejected)
+	}
+}
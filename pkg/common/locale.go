@@ -0,0 +1,200 @@
+package common
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// Localized holds champion, item & rune data of several locales, e.g. `en_US`, `zh_CN`, `ko_KR`.
+// Methods of a nil `Localized` return nil, so that output stays the same without `-locales`.
+type Localized struct {
+	Locales   []string
+	Champions map[string]map[string]ChampionItem
+	Items     map[string]map[string]BuildItem
+	Runes     map[string]IRuneLookUp
+}
+
+// GetLocalized fetches champion, item & rune data of `version` in every locale.
+func (d *DataDragon) GetLocalized(ctx context.Context, version string, locales []string) (*Localized, error) {
+	l := Localized{
+		Locales:   locales,
+		Champions: make(map[string]map[string]ChampionItem),
+		Items:     make(map[string]map[string]BuildItem),
+		Runes:     make(map[string]IRuneLookUp),
+	}
+
+	for _, locale := range locales {
+		champions, err := d.GetChampions(ctx, version, locale)
+		if err != nil {
+			return nil, err
+		}
+		items, err := d.GetItems(ctx, version, locale)
+		if err != nil {
+			return nil, err
+		}
+		runes, err := d.GetRunes(ctx, version, locale)
+		if err != nil {
+			return nil, err
+		}
+
+		l.Champions[locale] = champions.Data
+		l.Items[locale] = items.Data
+		l.Runes[locale], _ = MakeRuneLookUp(runes)
+	}
+
+	d.fetcher.logger.Info("got localized data", "version", version, "locales", strings.Join(locales, ","))
+	return &l, nil
+}
+
+// ChampionNames returns the name of champion `alias` in each locale.
+func (l *Localized) ChampionNames(alias string) map[string]string {
+	return l.Format(alias, func(name string) string {
+		return name
+	})
+}
+
+// ChampionTitles returns the title of champion `alias`, e.g. `the Nine-Tailed Fox`, in each locale.
+func (l *Localized) ChampionTitles(alias string) map[string]string {
+	if l == nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for _, locale := range l.Locales {
+		if c, ok := l.Champions[locale][alias]; ok {
+			result[locale] = c.Title
+		}
+	}
+	return result
+}
+
+// Format calls `format` with the name of champion `alias` in each locale,
+// it's used to generate localized build & rune titles.
+func (l *Localized) Format(alias string, format func(name string) string) map[string]string {
+	return l.FormatIn(alias, func(_ string, name string) string {
+		return format(name)
+	})
+}
+
+// FormatIn is `Format` which also passes the locale, for titles with fixed phrases, see `Phrase`.
+func (l *Localized) FormatIn(alias string, format func(locale string, name string) string) map[string]string {
+	if l == nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for _, locale := range l.Locales {
+		if c, ok := l.Champions[locale][alias]; ok {
+			result[locale] = format(locale, c.Name)
+		}
+	}
+	return result
+}
+
+// Each calls `format` in each locale, it's used to generate localized block types.
+func (l *Localized) Each(format func(locale string) string) map[string]string {
+	if l == nil {
+		return nil
+	}
+
+	result := make(map[string]string)
+	for _, locale := range l.Locales {
+		result[locale] = format(locale)
+	}
+	return result
+}
+
+// BlockTypes returns block type `blockType` in each locale, see `Phrase`,
+// `Boots` is the name of the basic boots in the locale.
+func (l *Localized) BlockTypes(blockType string) map[string]string {
+	return l.Each(func(locale string) string {
+		if blockType == `Boots` {
+			if name := l.ItemName(locale, BaseBootId); len(name) > 0 {
+				return name
+			}
+		}
+		return Phrase(locale, blockType)
+	})
+}
+
+// ItemName returns the name of item `id` in `locale`, empty if unknown.
+func (l *Localized) ItemName(locale string, id string) string {
+	if l == nil {
+		return ""
+	}
+	return l.Items[locale][id].Name
+}
+
+// RuneName returns the name of rune `id` in `locale`, empty if unknown.
+func (l *Localized) RuneName(locale string, id int) string {
+	if l == nil {
+		return ""
+	}
+	if r, ok := l.Runes[locale][id]; ok {
+		return r.Name
+	}
+	return ""
+}
+
+// phrases translates fixed parts of build titles, rune names & block types, keyed by the English
+// phrase, then by locale.
+var phrases = map[string]map[string]string{
+	"Highest Win":        {"zh_CN": "最高胜率", "zh_TW": "最高勝率", "ko_KR": "최고 승률", "ja_JP": "最高勝率"},
+	"Most Common":        {"zh_CN": "最常用", "zh_TW": "最常用", "ko_KR": "최다 선택", "ja_JP": "最多使用"},
+	"Recommended build":  {"zh_CN": "推荐出装", "zh_TW": "推薦出裝", "ko_KR": "추천 빌드", "ja_JP": "おすすめビルド"},
+	"Recommended Builds": {"zh_CN": "推荐出装", "zh_TW": "推薦出裝", "ko_KR": "추천 빌드", "ja_JP": "おすすめビルド"},
+	"Pick":               {"zh_CN": "选用", "zh_TW": "選用", "ko_KR": "픽", "ja_JP": "ピック"},
+	"Win Rate":           {"zh_CN": "胜率", "zh_TW": "勝率", "ko_KR": "승률", "ja_JP": "勝率"},
+	"win rate":           {"zh_CN": "胜率", "zh_TW": "勝率", "ko_KR": "승률", "ja_JP": "勝率"},
+	"Starter Items":      {"zh_CN": "起始装备", "zh_TW": "起始裝備", "ko_KR": "시작 아이템", "ja_JP": "開始アイテム"},
+	"Starting items":     {"zh_CN": "起始装备", "zh_TW": "起始裝備", "ko_KR": "시작 아이템", "ja_JP": "開始アイテム"},
+	"Core items":         {"zh_CN": "核心装备", "zh_TW": "核心裝備", "ko_KR": "핵심 아이템", "ja_JP": "コアアイテム"},
+	"Consumable Items":   {"zh_CN": "消耗品", "zh_TW": "消耗品", "ko_KR": "소모품", "ja_JP": "消耗品"},
+	"Consumables":        {"zh_CN": "消耗品", "zh_TW": "消耗品", "ko_KR": "소모품", "ja_JP": "消耗品"},
+	"Item":               {"zh_CN": "装备", "zh_TW": "裝備", "ko_KR": "아이템", "ja_JP": "アイテム"},
+}
+
+// Phrase translates a fixed `phrase` of titles into `locale`, it's kept in English without a translation.
+func Phrase(locale string, phrase string) string {
+	if t, ok := phrases[phrase][locale]; ok {
+		return t
+	}
+	return phrase
+}
+
+// NormalizeName lower cases `name` and keeps only letters & digits,
+// so that `Nunu & Willump` & `nunu willump` are the same.
+func NormalizeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, name)
+}
+
+// MakeAliasList maps normalized champion names of every locale, and aliases themselves, to aliases.
+func MakeAliasList(champions map[string]ChampionItem, l *Localized) map[string]string {
+	result := make(map[string]string)
+	add := func(list map[string]ChampionItem) {
+		for alias, c := range list {
+			result[NormalizeName(c.Name)] = alias
+			result[NormalizeName(alias)] = alias
+		}
+	}
+
+	add(champions)
+	if l != nil {
+		for _, locale := range l.Locales {
+			add(l.Champions[locale])
+		}
+	}
+	return result
+}
+
+// MatchAlias finds the alias of a champion by its name in any locale.
+func MatchAlias(aliasList map[string]string, name string) (string, bool) {
+	alias, ok := aliasList[NormalizeName(name)]
+	return alias, ok
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testLocalized() *Localized {
+	lookUp, _ := MakeRuneLookUp([]RuneSlot{{Id: 8100, Slots: []struct {
+		Runes []RespRuneItem `json:"runes"`
+	}{{Runes: []RespRuneItem{{Id: 8112, Name: "电刑"}}}}}})
+
+	return &Localized{
+		Locales: []string{"zh_CN", "de_DE"},
+		Champions: map[string]map[string]ChampionItem{
+			"zh_CN": {"Zed": {Name: "影流之主"}},
+			"de_DE": {"Zed": {Name: "Zed"}},
+		},
+		Items: map[string]map[string]BuildItem{
+			"zh_CN": {"1001": {Name: "鞋子"}},
+			"de_DE": {"1001": {Name: "Stiefel"}},
+		},
+		Runes: map[string]IRuneLookUp{"zh_CN": lookUp},
+	}
+}
+
+func TestLocalized_FormatIn(t *testing.T) {
+	got := testLocalized().FormatIn("Zed", func(locale string, name string) string {
+		return "[lolalytics] " + name + " " + Phrase(locale, "Highest Win")
+	})
+	expected := map[string]string{
+		"zh_CN": "[lolalytics] 影流之主 最高胜率",
+		"de_DE": "[lolalytics] Zed Highest Win",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("titles %v, expected %v", got, expected)
+	}
+
+	var l *Localized
+	if got := l.FormatIn("Zed", func(string, string) string { return "" }); got != nil {
+		t.Fatalf("titles %v without locales, expected nil", got)
+	}
+}
+
+func TestLocalized_BlockTypes(t *testing.T) {
+	l := testLocalized()
+
+	tests := []struct {
+		blockType string
+		expected  map[string]string
+	}{
+		{blockType: "Boots", expected: map[string]string{"zh_CN": "鞋子", "de_DE": "Stiefel"}},
+		{blockType: "Consumables", expected: map[string]string{"zh_CN": "消耗品", "de_DE": "Consumables"}},
+		{blockType: "Situational", expected: map[string]string{"zh_CN": "Situational", "de_DE": "Situational"}},
+	}
+
+	for _, tt := range tests {
+		if got := l.BlockTypes(tt.blockType); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("%s: types %v, expected %v", tt.blockType, got, tt.expected)
+		}
+	}
+}
+
+func TestLocalized_RuneName(t *testing.T) {
+	l := testLocalized()
+	if got := l.RuneName("zh_CN", 8112); got != "电刑" {
+		t.Fatalf("rune name %q, expected 电刑", got)
+	}
+	if got := l.RuneName("de_DE", 8112); got != "" {
+		t.Fatalf("rune name %q of a locale without runes, expected empty", got)
+	}
+}
@@ -0,0 +1,175 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (l Level) String() string {
+	if l < LevelDebug || l > LevelError {
+		return "unknown"
+	}
+	return levelNames[l]
+}
+
+func ParseLevel(s string) (Level, error) {
+	for i, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(i), nil
+		}
+	}
+
+	return LevelInfo, fmt.Errorf("unknown log level: %s", s)
+}
+
+const (
+	LogFormatText = `text`
+	LogFormatJSON = `json`
+)
+
+type logSink struct {
+	mu     sync.Mutex
+	out    io.Writer
+	level  Level
+	format string
+}
+
+// Logger writes leveled messages with key-value fields, in text or JSON lines.
+// Loggers derived by `With` share the same output.
+type Logger struct {
+	sink   *logSink
+	fields []interface{}
+}
+
+func NewLogger(out io.Writer, level Level, format string) (*Logger, error) {
+	if format != LogFormatText && format != LogFormatJSON {
+		return nil, fmt.Errorf("unknown log format: %s", format)
+	}
+
+	return &Logger{
+		sink: &logSink{
+			out:    out,
+			level:  level,
+			format: format,
+		},
+	}, nil
+}
+
+// Log is the default logger, replaced in `main` according to command line flags.
+var Log, _ = NewLogger(os.Stderr, LevelInfo, LogFormatText)
+
+// With returns a logger attaching `kv` pairs, e.g. `With("source", "op.gg")`, to every message.
+func (l *Logger) With(kv ...interface{}) *Logger {
+	fields := make([]interface{}, 0, len(l.fields)+len(kv))
+	fields = append(fields, l.fields...)
+	fields = append(fields, kv...)
+
+	return &Logger{sink: l.sink, fields: fields}
+}
+
+func (l *Logger) Debug(msg string, kv ...interface{}) {
+	l.log(LevelDebug, msg, kv)
+}
+
+func (l *Logger) Info(msg string, kv ...interface{}) {
+	l.log(LevelInfo, msg, kv)
+}
+
+func (l *Logger) Warn(msg string, kv ...interface{}) {
+	l.log(LevelWarn, msg, kv)
+}
+
+func (l *Logger) Error(msg string, kv ...interface{}) {
+	l.log(LevelError, msg, kv)
+}
+
+// Fatal logs at error level and exits the process.
+func (l *Logger) Fatal(msg string, kv ...interface{}) {
+	l.log(LevelError, msg, kv)
+	os.Exit(1)
+}
+
+func (l *Logger) Enabled(level Level) bool {
+	return level >= l.sink.level
+}
+
+func (l *Logger) log(level Level, msg string, kv []interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+
+	fields := append(append([]interface{}{}, l.fields...), kv...)
+	if len(fields)%2 != 0 {
+		fields = append(fields, "<missing>")
+	}
+
+	var buf bytes.Buffer
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+	if l.sink.format == LogFormatJSON {
+		buf.WriteString(`{"time":`)
+		writeJSONValue(&buf, now)
+		buf.WriteString(`,"level":`)
+		writeJSONValue(&buf, level.String())
+		buf.WriteString(`,"msg":`)
+		writeJSONValue(&buf, msg)
+		for i := 0; i < len(fields); i += 2 {
+			buf.WriteByte(',')
+			writeJSONValue(&buf, fmt.Sprint(fields[i]))
+			buf.WriteByte(':')
+			writeJSONValue(&buf, fieldValue(fields[i+1]))
+		}
+		buf.WriteString("}\n")
+	} else {
+		fmt.Fprintf(&buf, "%s %-5s %s", now, strings.ToUpper(level.String()), msg)
+		for i := 0; i < len(fields); i += 2 {
+			v := fmt.Sprint(fieldValue(fields[i+1]))
+			if strings.ContainsAny(v, " \t\n\"=") {
+				v = fmt.Sprintf("%q", v)
+			}
+			fmt.Fprintf(&buf, " %v=%s", fields[i], v)
+		}
+		buf.WriteByte('\n')
+	}
+
+	l.sink.mu.Lock()
+	defer l.sink.mu.Unlock()
+	_, _ = l.sink.out.Write(buf.Bytes())
+}
+
+// fieldValue turns values which don't marshal nicely into strings.
+func fieldValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case error:
+		return val.Error()
+	case time.Duration:
+		return val.String()
+	case fmt.Stringer:
+		return val.String()
+	}
+	return v
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b, _ = json.Marshal(fmt.Sprint(v))
+	}
+	buf.Write(b)
+}
@@ -0,0 +1,30 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const OutputDir = `output`
+
+// stagingDir holds packages being written, one level deeper than packages in `OutputDir`.
+const stagingDir = `.staging`
+
+// StagingDir is where sources write package `pkgName`, until it's validated & written to sinks.
+func StagingDir(pkgName string) string {
+	return filepath.Join(OutputDir, stagingDir, pkgName)
+}
+
+// PrepareStaging empties the staging dir of `pkgName`, left by a crashed run.
+func PrepareStaging(pkgName string) error {
+	dir := StagingDir(pkgName)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, os.ModePerm)
+}
+
+// DiscardPackage removes the staging dir of `pkgName`.
+func DiscardPackage(pkgName string) error {
+	return os.RemoveAll(StagingDir(pkgName))
+}
@@ -0,0 +1,134 @@
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// packMtime is the fixed mtime npm uses for tarball entries, so that the same content gives the same tarball.
+var packMtime = time.Date(1985, time.October, 26, 8, 15, 0, 0, time.UTC)
+
+// Tarball is an npm package tarball built by `Pack`.
+type Tarball struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	File    string `json:"file"`
+	Size    int    `json:"size"`
+	// Integrity is the sha512 Subresource Integrity string, e.g. `sha512-...`
+	Integrity string `json:"integrity"`
+	// Shasum is the hex sha1, still required by the registry
+	Shasum string   `json:"shasum"`
+	Files  []string `json:"files"`
+	// Manifest is the content of `package.json`
+	Manifest map[string]interface{} `json:"-"`
+}
+
+// TarballName returns the file name `npm pack` gives, e.g. `champ-r-op.gg-11.14.1-v1.tgz` for `@champ-r/op.gg`.
+func TarballName(name string, version string) string {
+	name = strings.Replace(strings.TrimPrefix(name, "@"), "/", "-", 1)
+	return name + "-" + version + ".tgz"
+}
+
+// Pack builds the npm tarball of the package in `dir` into `outDir`, with every file under `package/`.
+func Pack(dir string, outDir string) (*Tarball, error) {
+	var manifest map[string]interface{}
+	body, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err = json.Unmarshal(body, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: invalid package.json: %s", dir, err)
+	}
+	name, _ := manifest["name"].(string)
+	version, _ := manifest["version"].(string)
+	if len(name) == 0 || len(version) == 0 {
+		return nil, fmt.Errorf("%s: no name or version in package.json", dir)
+	}
+
+	files := make(map[string]string)
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range matches {
+		files[filepath.Base(m)] = m
+	}
+
+	data, err := makeTarball(files, "package/")
+	if err != nil {
+		return nil, err
+	}
+
+	t := Tarball{
+		Name:     name,
+		Version:  version,
+		File:     filepath.Join(outDir, TarballName(name, version)),
+		Size:     len(data),
+		Files:    GetKeys(files),
+		Manifest: manifest,
+	}
+	sort.Strings(t.Files)
+	sha512Sum := sha512.Sum512(data)
+	t.Integrity = "sha512-" + base64.StdEncoding.EncodeToString(sha512Sum[:])
+	sha1Sum := sha1.Sum(data)
+	t.Shasum = hex.EncodeToString(sha1Sum[:])
+
+	if err = os.MkdirAll(outDir, os.ModePerm); err != nil {
+		return nil, err
+	}
+	if err = writeFileAtomic(t.File, data); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// makeTarball gzips `files`, keyed by their name in the archive under `prefix`, in a stable order.
+func makeTarball(files map[string]string, prefix string) ([]byte, error) {
+	names := GetKeys(files)
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, name := range names {
+		body, err := ioutil.ReadFile(files[name])
+		if err != nil {
+			return nil, err
+		}
+
+		err = tw.WriteHeader(&tar.Header{
+			Name:     prefix + name,
+			Mode:     0644,
+			Size:     int64(len(body)),
+			ModTime:  packMtime,
+			Typeflag: tar.TypeReg,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = tw.Write(body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
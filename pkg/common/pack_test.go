@@ -0,0 +1,127 @@
+package common
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTarballName(t *testing.T) {
+	tests := []struct {
+		name, version, expected string
+	}{
+		{name: "@champ-r/op.gg", version: "11.14.1-v1", expected: "champ-r-op.gg-11.14.1-v1.tgz"},
+		{name: "op.gg", version: "1.0.0", expected: "op.gg-1.0.0.tgz"},
+	}
+	for _, tt := range tests {
+		if got := TarballName(tt.name, tt.version); got != tt.expected {
+			t.Errorf("%s: %s, expected %s", tt.name, got, tt.expected)
+		}
+	}
+}
+
+func TestPack(t *testing.T) {
+	root, err := ioutil.TempDir("", "pack")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	pkgDir := filepath.Join(root, "op.gg")
+	if err = os.MkdirAll(pkgDir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"package.json": `{"name":"@champ-r/op.gg","version":"11.14.1-v1"}`,
+		"Zed.json":     `[]`,
+		"Yone.json":    `[]`,
+		"README.md":    `not packed`,
+	}
+	for name, body := range files {
+		if err = ioutil.WriteFile(filepath.Join(pkgDir, name), []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	first, err := Pack(pkgDir, filepath.Join(root, "first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(first.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the mtime of the files must not leak into the tarball
+	later := time.Now().Add(time.Hour)
+	for name := range files {
+		_ = os.Chtimes(filepath.Join(pkgDir, name), later, later)
+	}
+	second, err := Pack(pkgDir, filepath.Join(root, "second"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ioutil.ReadFile(second.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sha512Sum := sha512.Sum512(data)
+	sha1Sum := sha1.Sum(data)
+	tests := []struct {
+		name          string
+		got, expected interface{}
+	}{
+		{name: "deterministic", got: bytes.Equal(data, again), expected: true},
+		{name: "file", got: filepath.Base(first.File), expected: "champ-r-op.gg-11.14.1-v1.tgz"},
+		{name: "name", got: first.Name, expected: "@champ-r/op.gg"},
+		{name: "version", got: first.Version, expected: "11.14.1-v1"},
+		{name: "size", got: first.Size, expected: len(data)},
+		{name: "integrity", got: first.Integrity, expected: "sha512-" + base64.StdEncoding.EncodeToString(sha512Sum[:])},
+		{name: "shasum", got: first.Shasum, expected: hex.EncodeToString(sha1Sum[:])},
+		{name: "same integrity", got: second.Integrity, expected: first.Integrity},
+		{name: "files", got: first.Files, expected: []string{"Yone.json", "Zed.json", "package.json"}},
+		{name: "entries", got: tarEntries(t, data), expected: []string{"package/Yone.json", "package/Zed.json", "package/package.json"}},
+	}
+
+	for _, tt := range tests {
+		if !reflect.DeepEqual(tt.got, tt.expected) {
+			t.Errorf("%s: %v, expected %v", tt.name, tt.got, tt.expected)
+		}
+	}
+}
+
+func tarEntries(t *testing.T, data []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	tr := tar.NewReader(gz)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !h.ModTime.Equal(packMtime) {
+			t.Fatalf("%s: mtime %s, expected %s", h.Name, h.ModTime, packMtime)
+		}
+		names = append(names, h.Name)
+	}
+}
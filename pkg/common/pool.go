@@ -0,0 +1,57 @@
+package common
+
+import (
+	"context"
+	"sync"
+)
+
+const DefaultConcurrency = 8
+
+// Pool runs jobs with a bounded number of goroutines.
+type Pool struct {
+	size int
+}
+
+func NewPool(size int) *Pool {
+	if size < 1 {
+		size = DefaultConcurrency
+	}
+
+	return &Pool{size: size}
+}
+
+// Run calls `job` for each index in [0, n), with at most `size` jobs in flight,
+// and returns the errors in the same order. Jobs are expected to store their results by index.
+// Once `ctx` is done, pending jobs are not started anymore and get `ctx.Err()`.
+func (p *Pool) Run(ctx context.Context, n int, job func(ctx context.Context, i int) error) []error {
+	errs := make([]error, n)
+	sem := make(chan struct{}, p.size)
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < n; i++ {
+		if ctx.Err() == nil {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}
+		if err := ctx.Err(); err != nil {
+			for j := i; j < n; j++ {
+				errs[j] = err
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = job(ctx, i)
+		}(i)
+	}
+
+	wg.Wait()
+	return errs
+}
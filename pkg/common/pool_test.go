@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPool_Run(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+		n    int
+	}{
+		{name: "no jobs", size: 2, n: 0},
+		{name: "fewer jobs than workers", size: 4, n: 2},
+		{name: "more jobs than workers", size: 3, n: 20},
+		{name: "default size", size: 0, n: 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var running, max int64
+			errs := NewPool(tt.size).Run(context.Background(), tt.n, func(ctx context.Context, i int) error {
+				cur := atomic.AddInt64(&running, 1)
+				defer atomic.AddInt64(&running, -1)
+				for {
+					m := atomic.LoadInt64(&max)
+					if cur <= m || atomic.CompareAndSwapInt64(&max, m, cur) {
+						break
+					}
+				}
+				if i%2 == 1 {
+					return fmt.Errorf("job %d", i)
+				}
+				return nil
+			})
+
+			size := tt.size
+			if size < 1 {
+				size = DefaultConcurrency
+			}
+			if max > int64(size) {
+				t.Fatalf("%d jobs in flight, expected at most %d", max, size)
+			}
+			if len(errs) != tt.n {
+				t.Fatalf("%d errors, expected %d", len(errs), tt.n)
+			}
+			for i, err := range errs {
+				if i%2 == 0 && err != nil {
+					t.Fatalf("job %d: unexpected error: %s", i, err)
+				}
+				if i%2 == 1 && (err == nil || err.Error() != fmt.Sprintf("job %d", i)) {
+					t.Fatalf("job %d: error %v, expected its own error", i, err)
+				}
+			}
+		})
+	}
+}
+
+func TestPool_Run_cancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		started int
+	}{
+		{name: "cancelled before", started: 0},
+		{name: "cancelled by a job", started: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			if tt.started == 0 {
+				cancel()
+			}
+
+			var mu sync.Mutex
+			ran := map[int]bool{}
+			errs := NewPool(1).Run(ctx, 5, func(ctx context.Context, i int) error {
+				mu.Lock()
+				ran[i] = true
+				mu.Unlock()
+				if i == tt.started-1 {
+					cancel()
+				}
+				return nil
+			})
+
+			for i, err := range errs {
+				if i < tt.started {
+					if !ran[i] || err != nil {
+						t.Fatalf("job %d: ran %t, error %v, expected to finish", i, ran[i], err)
+					}
+					continue
+				}
+				if ran[i] || !errors.Is(err, context.Canceled) {
+					t.Fatalf("job %d: ran %t, error %v, expected not to start", i, ran[i], err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const DefaultRateLimit = 2
+const DefaultRateBurst = 5
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket limiter keeping one bucket per host,
+// each bucket refills `rate` tokens per second, up to `burst` tokens.
+type RateLimiter struct {
+	rate    float64
+	burst   int
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &RateLimiter{
+		rate:    rate,
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// reserve takes a token from the bucket of `host`, and returns how long the caller has to wait for it.
+func (l *RateLimiter) reserve(host string) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, ok := l.buckets[host]
+	if !ok {
+		b = &bucket{tokens: float64(l.burst), last: now}
+		l.buckets[host] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > float64(l.burst) {
+		b.tokens = float64(l.burst)
+	}
+	b.last = now
+	b.tokens -= 1
+
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / l.rate * float64(time.Second))
+}
+
+// Wait blocks until a request to `host` is allowed or `ctx` is done,
+// a nil or unlimited limiter never blocks.
+func (l *RateLimiter) Wait(ctx context.Context, host string) error {
+	if l == nil || l.rate <= 0 {
+		return ctx.Err()
+	}
+
+	if err := Sleep(ctx, l.reserve(host)); err != nil {
+		l.release(host)
+		return err
+	}
+	return nil
+}
+
+// release gives back the token reserved for a request which is never sent.
+func (l *RateLimiter) release(host string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, ok := l.buckets[host]; ok && b.tokens < float64(l.burst) {
+		b.tokens += 1
+	}
+}
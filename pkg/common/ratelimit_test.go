@@ -0,0 +1,87 @@
+package common
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter_reserve(t *testing.T) {
+	tests := []struct {
+		name     string
+		rate     float64
+		burst    int
+		hosts    []string
+		expected []time.Duration
+	}{
+		{
+			name: "burst", rate: 10, burst: 3,
+			hosts:    []string{"op.gg", "op.gg", "op.gg"},
+			expected: []time.Duration{0, 0, 0},
+		},
+		{
+			name: "exhausted", rate: 10, burst: 2,
+			hosts:    []string{"op.gg", "op.gg", "op.gg", "op.gg"},
+			expected: []time.Duration{0, 0, 100 * time.Millisecond, 200 * time.Millisecond},
+		},
+		{
+			name: "bucket per host", rate: 10, burst: 1,
+			hosts:    []string{"op.gg", "lolalytics.com", "op.gg"},
+			expected: []time.Duration{0, 0, 100 * time.Millisecond},
+		},
+		{
+			name: "burst defaults to 1", rate: 2, burst: 0,
+			hosts:    []string{"op.gg", "op.gg"},
+			expected: []time.Duration{0, 500 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := NewRateLimiter(tt.rate, tt.burst)
+			for i, host := range tt.hosts {
+				got := l.reserve(host)
+				// tokens refill while the test runs
+				if got > tt.expected[i] || got < tt.expected[i]-10*time.Millisecond {
+					t.Fatalf("request %d to %s waits %s, expected %s", i, host, got, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRateLimiter_Wait(t *testing.T) {
+	l := NewRateLimiter(10, 1)
+	ctx := context.Background()
+	if err := l.Wait(ctx, "op.gg"); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	if err := l.Wait(ctx, "op.gg"); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 80*time.Millisecond {
+		t.Fatalf("waited %s, expected about 100ms", d)
+	}
+
+	var unlimited *RateLimiter
+	if err := unlimited.Wait(ctx, "op.gg"); err != nil {
+		t.Fatalf("nil limiter: %s", err)
+	}
+}
+
+func TestRateLimiter_release(t *testing.T) {
+	l := NewRateLimiter(1, 1)
+	l.reserve("op.gg")
+
+	// the cancelled request gives its token back, so the next one doesn't queue behind it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, "op.gg"); err == nil {
+		t.Fatal("expected the deadline to expire while waiting")
+	}
+	if d := l.reserve("op.gg"); d > time.Second {
+		t.Fatalf("waits %s, expected at most 1s", d)
+	}
+}
@@ -0,0 +1,227 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const DefaultRegistry = `https://registry.npmjs.org/`
+const DefaultDistTag = `latest`
+
+// Registry publishes packages by the npm registry HTTP protocol, so that neither npm nor node is needed.
+type Registry struct {
+	url    *url.URL
+	token  string
+	client *http.Client
+}
+
+func NewRegistry(registry string, token string) (*Registry, error) {
+	u, err := url.Parse(registry)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid registry: %s", registry)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		u.Path += "/"
+	}
+
+	return &Registry{
+		url:    u,
+		token:  token,
+		client: &http.Client{Timeout: 2 * time.Minute},
+	}, nil
+}
+
+// pkgUrl returns the url of package document `name`, scoped names are escaped as `@scope%2fname`.
+func (r *Registry) pkgUrl(name string) string {
+	return r.url.String() + strings.Replace(name, "/", "%2f", 1)
+}
+
+func (r *Registry) do(ctx context.Context, method string, u string, body interface{}, v interface{}) error {
+	var reqBody []byte
+	if body != nil {
+		var err error
+		if reqBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(reqBody))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(r.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	res, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var e struct {
+			Error  string `json:"error"`
+			Reason string `json:"reason"`
+		}
+		_ = json.Unmarshal(resBody, &e)
+		httpErr := &HTTPError{Url: u, StatusCode: res.StatusCode, Status: res.Status}
+		if msg := strings.TrimSpace(e.Error + " " + e.Reason); len(msg) > 0 {
+			return fmt.Errorf("%w: %s", httpErr, msg)
+		}
+		return httpErr
+	}
+
+	if v != nil {
+		return json.Unmarshal(resBody, v)
+	}
+	return nil
+}
+
+// LatestContentHash returns `contentHash` of the version tagged `tag`, empty if the package doesn't exist yet.
+func (r *Registry) LatestContentHash(ctx context.Context, name string, tag string) (string, error) {
+	var doc struct {
+		DistTags map[string]string `json:"dist-tags"`
+		Versions map[string]struct {
+			ContentHash string `json:"contentHash"`
+		} `json:"versions"`
+	}
+
+	err := r.do(ctx, http.MethodGet, r.pkgUrl(name), nil, &doc)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) && httpErr.StatusCode == http.StatusNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return doc.Versions[doc.DistTags[tag]].ContentHash, nil
+}
+
+// Publish uploads tarball `t` tagged with `tags[0]`, and adds the rest of `tags` to the new version.
+func (r *Registry) Publish(ctx context.Context, t *Tarball, tags []string) error {
+	if len(tags) == 0 {
+		tags = []string{DefaultDistTag}
+	}
+
+	data, err := ioutil.ReadFile(t.File)
+	if err != nil {
+		return err
+	}
+
+	// attachments are named without the scope, e.g. `op.gg-11.14.1-v1.tgz`
+	fileName := path.Base(t.Name) + "-" + t.Version + ".tgz"
+	version := make(map[string]interface{})
+	for k, v := range t.Manifest {
+		version[k] = v
+	}
+	version["_id"] = t.Name + "@" + t.Version
+	version["dist"] = map[string]interface{}{
+		"integrity": t.Integrity,
+		"shasum":    t.Shasum,
+		"tarball":   r.url.String() + t.Name + "/-/" + fileName,
+	}
+
+	doc := map[string]interface{}{
+		"_id":         t.Name,
+		"name":        t.Name,
+		"description": t.Manifest["description"],
+		"access":      "public",
+		"dist-tags":   map[string]string{tags[0]: t.Version},
+		"versions":    map[string]interface{}{t.Version: version},
+		"_attachments": map[string]interface{}{
+			fileName: map[string]interface{}{
+				"content_type": "application/octet-stream",
+				"data":         base64.StdEncoding.EncodeToString(data),
+				"length":       len(data),
+			},
+		},
+	}
+	if err = r.do(ctx, http.MethodPut, r.pkgUrl(t.Name), doc, nil); err != nil {
+		return err
+	}
+
+	for _, tag := range tags[1:] {
+		if err = r.SetDistTag(ctx, t.Name, t.Version, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) SetDistTag(ctx context.Context, name string, version string, tag string) error {
+	u := r.url.String() + "-/package/" + strings.Replace(name, "/", "%2f", 1) + "/dist-tags/" + url.PathEscape(tag)
+	return r.do(ctx, http.MethodPut, u, version, nil)
+}
+
+// PatchTag returns the dist-tag of the patch of `version`, e.g. `patch-11.14` for `11.14.1-v1626574447913`.
+func PatchTag(version string) string {
+	v := strings.SplitN(version, "-", 2)[0]
+	parts := strings.Split(v, ".")
+	if len(parts) > 2 {
+		parts = parts[:2]
+	}
+	return "patch-" + strings.Join(parts, ".")
+}
+
+// NpmToken finds the auth token of `registry`, from `NPM_TOKEN`, or the `_authToken` of the registry
+// in the user `.npmrc`, which is where `npm adduser` saves it. Environment variables in `.npmrc` are expanded,
+// e.g. `${NODE_AUTH_TOKEN}` set by `actions/setup-node`.
+func NpmToken(registry string) string {
+	if v := os.Getenv("NPM_TOKEN"); len(v) > 0 {
+		return v
+	}
+
+	npmrc := os.Getenv("NPM_CONFIG_USERCONFIG")
+	if len(npmrc) == 0 {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		npmrc = filepath.Join(home, ".npmrc")
+	}
+	f, err := os.Open(npmrc)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	u, err := url.Parse(registry)
+	if err != nil {
+		return ""
+	}
+	prefix := "//" + u.Host + strings.TrimSuffix(u.Path, "/") + "/:_authToken="
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, prefix) {
+			return os.ExpandEnv(strings.TrimPrefix(line, prefix))
+		}
+	}
+	return ""
+}
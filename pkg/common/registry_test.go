@@ -0,0 +1,185 @@
+package common
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type registryRequest struct {
+	method string
+	uri    string
+	auth   string
+	body   []byte
+}
+
+// testRegistry records the requests it gets, & answers with `status` & `body`.
+func testRegistry(t *testing.T, status int, body string) (*httptest.Server, *[]registryRequest) {
+	var requests []registryRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		requests = append(requests, registryRequest{method: r.Method, uri: r.RequestURI, auth: r.Header.Get("Authorization"), body: b})
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	return srv, &requests
+}
+
+func TestRegistry_Publish(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "champ-r-op.gg-11.14.1-v1.tgz")
+	if err = ioutil.WriteFile(file, []byte("tarball"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tarball := &Tarball{
+		Name:      "@champ-r/op.gg",
+		Version:   "11.14.1-v1",
+		File:      file,
+		Integrity: "sha512-abc",
+		Shasum:    "def",
+		Manifest:  map[string]interface{}{"name": "@champ-r/op.gg", "version": "11.14.1-v1", "description": "op.gg"},
+	}
+
+	tests := []struct {
+		name     string
+		tags     []string
+		distTags []string
+	}{
+		{name: "default tag", distTags: nil},
+		{name: "latest", tags: []string{"latest"}},
+		{name: "patch tag", tags: []string{"latest", "patch-11.14"}, distTags: []string{"patch-11.14"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := testRegistry(t, http.StatusOK, `{}`)
+			defer srv.Close()
+			r, err := NewRegistry(srv.URL, "token")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = r.Publish(context.Background(), tarball, tt.tags); err != nil {
+				t.Fatal(err)
+			}
+			if len(*requests) != 1+len(tt.distTags) {
+				t.Fatalf("%d requests, expected %d", len(*requests), 1+len(tt.distTags))
+			}
+
+			put := (*requests)[0]
+			if put.method != http.MethodPut || put.uri != "/@champ-r%2fop.gg" || put.auth != "Bearer token" {
+				t.Fatalf("%s %s (%s), expected an authorized PUT of the package document", put.method, put.uri, put.auth)
+			}
+			var doc struct {
+				Name        string                            `json:"name"`
+				DistTags    map[string]string                 `json:"dist-tags"`
+				Versions    map[string]map[string]interface{} `json:"versions"`
+				Attachments map[string]struct {
+					Data   string `json:"data"`
+					Length int    `json:"length"`
+				} `json:"_attachments"`
+			}
+			if err = json.Unmarshal(put.body, &doc); err != nil {
+				t.Fatal(err)
+			}
+			if doc.Name != "@champ-r/op.gg" || !reflect.DeepEqual(doc.DistTags, map[string]string{"latest": "11.14.1-v1"}) {
+				t.Fatalf("name %s, dist-tags %v", doc.Name, doc.DistTags)
+			}
+			dist := doc.Versions["11.14.1-v1"]["dist"]
+			expectedDist := map[string]interface{}{
+				"integrity": "sha512-abc",
+				"shasum":    "def",
+				"tarball":   srv.URL + "/@champ-r/op.gg/-/op.gg-11.14.1-v1.tgz",
+			}
+			if !reflect.DeepEqual(dist, expectedDist) {
+				t.Fatalf("dist %v, expected %v", dist, expectedDist)
+			}
+			a, ok := doc.Attachments["op.gg-11.14.1-v1.tgz"]
+			if !ok || a.Data != base64.StdEncoding.EncodeToString([]byte("tarball")) || a.Length != len("tarball") {
+				t.Fatalf("attachments %+v, expected the tarball", doc.Attachments)
+			}
+
+			for i, tag := range tt.distTags {
+				req := (*requests)[i+1]
+				if req.method != http.MethodPut || req.uri != "/-/package/@champ-r%2fop.gg/dist-tags/"+tag || string(req.body) != `"11.14.1-v1"` {
+					t.Fatalf("%s %s %s, expected dist-tag %s", req.method, req.uri, req.body, tag)
+				}
+			}
+		})
+	}
+}
+
+func TestRegistry_LatestContentHash(t *testing.T) {
+	doc := `{"dist-tags":{"latest":"1.0.1"},"versions":{"1.0.0":{"contentHash":"old"},"1.0.1":{"contentHash":"new"}}}`
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		tag      string
+		expected string
+		err      string
+	}{
+		{name: "latest", status: http.StatusOK, body: doc, tag: "latest", expected: "new"},
+		{name: "unknown tag", status: http.StatusOK, body: doc, tag: "next"},
+		{name: "not published", status: http.StatusNotFound, body: `{"error":"not_found"}`, tag: "latest"},
+		{name: "error", status: http.StatusUnauthorized, body: `{"error":"unauthorized","reason":"bad token"}`, tag: "latest", err: "unauthorized bad token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, requests := testRegistry(t, tt.status, tt.body)
+			defer srv.Close()
+			r, err := NewRegistry(srv.URL+"/npm", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := r.LatestContentHash(context.Background(), "@champ-r/op.gg", tt.tag)
+			if len(tt.err) > 0 {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("error %v, expected %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.expected {
+				t.Fatalf("hash %q, expected %q", got, tt.expected)
+			}
+			if req := (*requests)[0]; req.method != http.MethodGet || req.uri != "/npm/@champ-r%2fop.gg" || len(req.auth) > 0 {
+				t.Fatalf("%s %s (%s), expected an anonymous GET of the package document", req.method, req.uri, req.auth)
+			}
+		})
+	}
+}
+
+func TestPatchTag(t *testing.T) {
+	tests := []struct {
+		version, expected string
+	}{
+		{version: "11.14.1-v1626574447913", expected: "patch-11.14"},
+		{version: "11.14", expected: "patch-11.14"},
+		{version: "11", expected: "patch-11"},
+	}
+	for _, tt := range tests {
+		if got := PatchTag(tt.version); got != tt.expected {
+			t.Errorf("%s: %s, expected %s", tt.version, got, tt.expected)
+		}
+	}
+}
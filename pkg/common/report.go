@@ -0,0 +1,46 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const RunReportFile = `run.json`
+
+// SourceReport is the outcome of a source in the run report,
+// `Error` is set when the source failed as a whole.
+type SourceReport struct {
+	*Result
+	Error   string `json:"error,omitempty"`
+	Healthy bool   `json:"healthy"`
+	// Updated is set when the package is replaced by this run, a failed or unhealthy source keeps the previous one.
+	Updated bool `json:"updated"`
+	// ContentHash is set when the package is written, and `Unchanged` when it equals
+	// the hash of the last published version, see `PublishState`.
+	ContentHash string `json:"contentHash,omitempty"`
+	Unchanged   bool   `json:"unchanged"`
+}
+
+// RunReport is written to `output/run.json` after each crawl,
+// so scheduled jobs can decide whether to publish without parsing logs.
+type RunReport struct {
+	Args            []string       `json:"args"`
+	OfficialVersion string         `json:"officialVersion"`
+	StartedAt       time.Time      `json:"startedAt"`
+	FinishedAt      time.Time      `json:"finishedAt"`
+	Duration        time.Duration  `json:"duration"`
+	Stats           FetchStats     `json:"stats"`
+	Sources         []SourceReport `json:"sources"`
+	// Error is set when the run fails before crawling, e.g. Data Dragon is unavailable.
+	Error    string `json:"error,omitempty"`
+	ExitCode int    `json:"exitCode"`
+}
+
+func (r *RunReport) Save(dir string) error {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return err
+	}
+
+	return SaveJSON(filepath.Join(dir, RunReportFile), r)
+}
@@ -0,0 +1,117 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"time"
+)
+
+// Issue explains why a champion (at a position) failed or was skipped.
+type Issue struct {
+	Champion   string `json:"champion"`
+	Position   string `json:"position,omitempty"`
+	Reason     string `json:"reason"`
+	Url        string `json:"url,omitempty"`
+	StatusCode int    `json:"statusCode,omitempty"`
+}
+
+// NewIssue makes an issue from `err`, with the url & status of the failed request if any.
+func NewIssue(champion string, position string, err error) Issue {
+	issue := Issue{
+		Champion: champion,
+		Position: position,
+		Reason:   err.Error(),
+	}
+
+	var httpErr *HTTPError
+	var urlErr *url.Error
+	if errors.As(err, &httpErr) {
+		issue.Url = httpErr.Url
+		issue.StatusCode = httpErr.StatusCode
+	} else if errors.As(err, &urlErr) {
+		issue.Url = urlErr.URL
+	}
+
+	return issue
+}
+
+// Result is the outcome of a source, one job is a champion or a champion at a position.
+type Result struct {
+	Source          string  `json:"source"`
+	PkgName         string  `json:"pkgName"`
+	OfficialVersion string  `json:"officialVersion"`
+	SourceVersion   string  `json:"sourceVersion"`
+	Attempted       int     `json:"attempted"`
+	Succeeded       int     `json:"succeeded"`
+	Failed          []Issue `json:"failed"`
+	Skipped         []Issue `json:"skipped"`
+	// Rejected lists generated data dropped by validation, e.g. illegal rune pages.
+	Rejected   []Issue       `json:"rejected"`
+	StartedAt  time.Time     `json:"startedAt"`
+	FinishedAt time.Time     `json:"finishedAt"`
+	Duration   time.Duration `json:"duration"`
+	Stats      FetchStats    `json:"stats"`
+	// Produced lists the positions generated for each champion alias.
+	Produced map[string][]string `json:"produced"`
+}
+
+func NewResult(pkgName string) *Result {
+	return &Result{
+		PkgName:   pkgName,
+		StartedAt: time.Now(),
+		Produced:  make(map[string][]string),
+	}
+}
+
+func (r *Result) Succeed(champion string, position string) {
+	r.Succeeded += 1
+	if len(position) == 0 {
+		if _, ok := r.Produced[champion]; !ok {
+			r.Produced[champion] = []string{}
+		}
+		return
+	}
+
+	r.Produced[champion] = append(r.Produced[champion], position)
+}
+
+// Fail records a failed job, jobs cut short because `ctx` is done (SIGINT or `-timeout`) are
+// recorded as skipped instead, so that they don't count against the failure rate.
+func (r *Result) Fail(ctx context.Context, champion string, position string, err error) {
+	if ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		r.Skip(champion, position, "cancelled: "+err.Error())
+		return
+	}
+	r.Failed = append(r.Failed, NewIssue(champion, position, err))
+}
+
+func (r *Result) Skip(champion string, position string, reason string) {
+	r.Skipped = append(r.Skipped, Issue{
+		Champion: champion,
+		Position: position,
+		Reason:   reason,
+	})
+}
+
+func (r *Result) Reject(champion string, position string, reason string) {
+	r.Rejected = append(r.Rejected, Issue{
+		Champion: champion,
+		Position: position,
+		Reason:   reason,
+	})
+}
+
+func (r *Result) Finish() {
+	r.FinishedAt = time.Now()
+	r.Duration = r.FinishedAt.Sub(r.StartedAt)
+}
+
+// FailureRate is the ratio of failed jobs, a source which attempted nothing is considered failed.
+func (r *Result) FailureRate() float64 {
+	if r.Attempted == 0 {
+		return 1
+	}
+
+	return float64(len(r.Failed)) / float64(r.Attempted)
+}
@@ -0,0 +1,40 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+)
+
+func TestResult_Fail(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		err     error
+		skipped bool
+	}{
+		{name: "failed", ctx: context.Background(), err: errors.New("champion data not ready")},
+		{name: "attempt timeout", ctx: context.Background(), err: &url.Error{Op: "Get", URL: "https://op.gg", Err: context.DeadlineExceeded}},
+		{name: "not started", ctx: cancelled, err: context.Canceled, skipped: true},
+		{name: "in flight", ctx: cancelled, err: &url.Error{Op: "Get", URL: "https://op.gg", Err: context.Canceled}, skipped: true},
+		{name: "failed before cancellation", ctx: cancelled, err: errors.New("champion data not ready")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResult("test")
+			r.Attempted = 1
+			r.Fail(tt.ctx, "Zed", "middle", tt.err)
+			if skipped := len(r.Skipped) == 1 && len(r.Failed) == 0; skipped != tt.skipped {
+				t.Fatalf("failed %+v, skipped %+v", r.Failed, r.Skipped)
+			}
+			if rate := r.FailureRate(); tt.skipped != (rate == 0) {
+				t.Fatalf("failure rate %v", rate)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy decides how many times & how long to wait before a failed request is sent again.
+type RetryPolicy struct {
+	// Attempts is the total number of attempts, 1 means never retry.
+	Attempts int
+	// BaseDelay is the delay before the first retry, it doubles on each retry.
+	BaseDelay time.Duration
+	// MaxDelay caps both the backoff & the `Retry-After` header.
+	MaxDelay time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:  3,
+	BaseDelay: 500 * time.Millisecond,
+	MaxDelay:  30 * time.Second,
+}
+
+// HTTPError is returned for any non 200 response.
+type HTTPError struct {
+	Url        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
+}
+
+func (e *HTTPError) Error() string {
+	return e.Status + " (" + e.Url + ")"
+}
+
+// isRetryable reports whether a response with `statusCode` is worth sending again.
+func isRetryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode >= 500
+}
+
+// parseRetryAfter reads the `Retry-After` header, in either seconds or HTTP date.
+func parseRetryAfter(v string) time.Duration {
+	if len(v) == 0 {
+		return 0
+	}
+
+	if sec, err := strconv.Atoi(v); err == nil {
+		return time.Duration(sec) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// Sleep pauses for `d`, and returns early with the error of `ctx` once it's done.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// delay returns the wait before the next attempt, `attempt` starts from 1.
+// `retryAfter` from the server takes precedence over the backoff.
+func (p RetryPolicy) delay(attempt int, retryAfter time.Duration) time.Duration {
+	d := retryAfter
+	if d <= 0 {
+		backoff := p.BaseDelay << uint(attempt-1)
+		if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+			backoff = p.MaxDelay
+		}
+		// equal jitter, half of the backoff plus a random half, within [backoff/2, backoff)
+		half := int64(backoff / 2)
+		if half > 0 {
+			d = time.Duration(half + rand.Int63n(half))
+		}
+	}
+
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d
+}
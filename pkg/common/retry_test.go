@@ -0,0 +1,112 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicy_delay(t *testing.T) {
+	p := RetryPolicy{Attempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	tests := []struct {
+		name       string
+		attempt    int
+		retryAfter time.Duration
+		min, max   time.Duration
+	}{
+		{name: "first retry", attempt: 1, min: 50 * time.Millisecond, max: 100 * time.Millisecond},
+		{name: "doubles", attempt: 2, min: 100 * time.Millisecond, max: 200 * time.Millisecond},
+		{name: "third retry", attempt: 3, min: 200 * time.Millisecond, max: 400 * time.Millisecond},
+		{name: "capped", attempt: 10, min: 500 * time.Millisecond, max: time.Second},
+		{name: "overflow", attempt: 80, min: 500 * time.Millisecond, max: time.Second},
+		{name: "retry after", attempt: 1, retryAfter: 700 * time.Millisecond, min: 700 * time.Millisecond, max: 700 * time.Millisecond},
+		{name: "retry after capped", attempt: 1, retryAfter: time.Minute, min: time.Second, max: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				if d := p.delay(tt.attempt, tt.retryAfter); d < tt.min || d > tt.max {
+					t.Fatalf("delay %s, expected within [%s, %s]", d, tt.min, tt.max)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		v        string
+		min, max time.Duration
+	}{
+		{name: "empty", v: ""},
+		{name: "seconds", v: "3", min: 3 * time.Second, max: 3 * time.Second},
+		{name: "http date", v: time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat), min: 8 * time.Second, max: 10 * time.Second},
+		{name: "past date", v: "Fri, 24 May 2013 00:00:00 GMT", min: -1 << 63, max: 0},
+		{name: "invalid", v: "soon"},
+	}
+
+	for _, tt := range tests {
+		if d := parseRetryAfter(tt.v); d < tt.min || d > tt.max {
+			t.Errorf("%s: %s, expected within [%s, %s]", tt.name, d, tt.min, tt.max)
+		}
+	}
+}
+
+func TestFetcher_MakeRequest_retryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int64
+	}{
+		{name: "too many requests", status: http.StatusTooManyRequests, attempts: 2},
+		{name: "unavailable", status: http.StatusServiceUnavailable, attempts: 2},
+		{name: "not found", status: http.StatusNotFound, attempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var n int64
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt64(&n, 1) == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte("ok"))
+			}))
+			defer srv.Close()
+
+			// the backoff alone would retry at once, waiting means `Retry-After` was obeyed
+			f, err := NewFetcher(FetcherOptions{Retry: RetryPolicy{Attempts: 3, BaseDelay: time.Nanosecond, MaxDelay: 5 * time.Second}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			_, err = f.MakeRequest(context.Background(), srv.URL)
+			if got := atomic.LoadInt64(&n); got != tt.attempts {
+				t.Fatalf("%d attempts, expected %d", got, tt.attempts)
+			}
+			if tt.attempts == 1 {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d := time.Since(start); d < 900*time.Millisecond {
+				t.Fatalf("retried after %s, expected Retry-After of 1s", d)
+			}
+			if s := f.Stats(); s.Requests != 2 || s.Retries != 1 || s.Failures != 0 {
+				t.Fatalf("stats %+v", s)
+			}
+		})
+	}
+}
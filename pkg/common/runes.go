@@ -0,0 +1,110 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RuneValidator checks that a rune page could be saved in the client:
+// a keystone & one rune of each other row in the primary style, two runes of different rows
+// in another style, and one shard of each row in `Fragments`.
+type RuneValidator struct {
+	lookUp IRuneLookUp
+	// rows is the number of rows of each style, the keystone row included
+	rows map[int]int
+}
+
+func NewRuneValidator(lookUp IRuneLookUp, allRunes IAllRunes) *RuneValidator {
+	v := RuneValidator{
+		lookUp: lookUp,
+		rows:   make(map[int]int),
+	}
+	if allRunes != nil {
+		for _, style := range *allRunes {
+			v.rows[style.Id] = len(style.Slots)
+		}
+	}
+
+	return &v
+}
+
+func (v *RuneValidator) Validate(page RuneItem) error {
+	primaryRows, ok := v.rows[page.PrimaryStyleId]
+	if !ok {
+		return fmt.Errorf("unknown primary style %d", page.PrimaryStyleId)
+	}
+	if _, ok := v.rows[page.SubStyleId]; !ok {
+		return fmt.Errorf("unknown secondary style %d", page.SubStyleId)
+	}
+	if page.SubStyleId == page.PrimaryStyleId {
+		return errors.New("secondary style is the same as primary style")
+	}
+
+	primary := make(map[int]int)
+	secondary := make(map[int]int)
+	var shards []int
+	for _, id := range page.SelectedPerkIds {
+		r, ok := v.lookUp[id]
+		if !ok {
+			shards = append(shards, id)
+			continue
+		}
+
+		switch r.Style {
+		case page.PrimaryStyleId:
+			if _, ok := primary[r.Slot]; ok {
+				return fmt.Errorf("rune %d is in the same row %d as rune %d", id, r.Slot, primary[r.Slot])
+			}
+			primary[r.Slot] = id
+		case page.SubStyleId:
+			if r.Primary {
+				return fmt.Errorf("keystone %d in secondary style", id)
+			}
+			if _, ok := secondary[r.Slot]; ok {
+				return fmt.Errorf("rune %d is in the same row %d as rune %d", id, r.Slot, secondary[r.Slot])
+			}
+			secondary[r.Slot] = id
+		default:
+			return fmt.Errorf("rune %d is in neither primary nor secondary style", id)
+		}
+	}
+
+	if len(primary) != primaryRows {
+		return fmt.Errorf("%d runes in primary style, expected %d", len(primary), primaryRows)
+	}
+	if len(secondary) != 2 {
+		return fmt.Errorf("%d runes in secondary style, expected 2", len(secondary))
+	}
+	if len(shards) != len(Fragments) {
+		return fmt.Errorf("%d shards, expected %d", len(shards), len(Fragments))
+	}
+	for i, id := range shards {
+		if !includesInt(id, Fragments[i]) {
+			return fmt.Errorf("invalid shard %d in row %d", id, i)
+		}
+	}
+
+	return nil
+}
+
+// DropInvalid removes invalid rune pages of `item`, and records why in `result`.
+func (v *RuneValidator) DropInvalid(result *Result, item *ChampionDataItem) {
+	runes := item.Runes[:0]
+	for _, page := range item.Runes {
+		if err := v.Validate(page); err != nil {
+			result.Reject(item.Alias, item.Position, "rune page "+page.Name+": "+err.Error())
+			continue
+		}
+		runes = append(runes, page)
+	}
+	item.Runes = runes
+}
+
+func includesInt(target int, list []int) bool {
+	for _, i := range list {
+		if i == target {
+			return true
+		}
+	}
+	return false
+}
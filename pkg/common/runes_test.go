@@ -0,0 +1,151 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+// testRunes is a subset of `runesReforged.json`, Precision & Domination with the rows of real data.
+func testRunes() []RuneSlot {
+	style := func(id int, rows ...[]int) RuneSlot {
+		s := RuneSlot{Id: id}
+		for _, row := range rows {
+			var runes []RespRuneItem
+			for _, r := range row {
+				runes = append(runes, RespRuneItem{Id: r})
+			}
+			s.Slots = append(s.Slots, struct {
+				Runes []RespRuneItem `json:"runes"`
+			}{Runes: runes})
+		}
+		return s
+	}
+
+	return []RuneSlot{
+		style(8000, []int{8005, 8008, 8021, 8010}, []int{9101, 9111, 8009}, []int{9104, 9105, 9103}, []int{8014, 8017, 8299}),
+		style(8100, []int{8112, 8124, 8128, 9923}, []int{8126, 8139, 8143}, []int{8136, 8120, 8138}, []int{8135, 8134, 8105, 8106}),
+	}
+}
+
+func TestRuneValidator_Validate(t *testing.T) {
+	v := NewRuneValidator(MakeRuneLookUp(testRunes()))
+
+	tests := []struct {
+		name  string
+		style int
+		sub   int
+		perks []int
+		err   string
+	}{
+		{
+			name:  "valid",
+			style: 8000, sub: 8100,
+			perks: []int{8010, 9111, 9104, 8299, 8143, 8135, 5005, 5008, 5002},
+		},
+		{
+			name:  "missing keystone",
+			style: 8000, sub: 8100,
+			perks: []int{9111, 9104, 8299, 8143, 8135, 5005, 5008, 5002},
+			err:   "3 runes in primary style, expected 4",
+		},
+		{
+			name:  "duplicate row",
+			style: 8000, sub: 8100,
+			perks: []int{8010, 9111, 9101, 8299, 8143, 8135, 5005, 5008, 5002},
+			err:   "rune 9101 is in the same row 1 as rune 9111",
+		},
+		{
+			name:  "duplicate row in secondary style",
+			style: 8000, sub: 8100,
+			perks: []int{8010, 9111, 9104, 8299, 8136, 8138, 5005, 5008, 5002},
+			err:   "rune 8138 is in the same row 2 as rune 8136",
+		},
+		{
+			name:  "keystone in secondary style",
+			style: 8000, sub: 8100,
+			perks: []int{8010, 9111, 9104, 8299, 8112, 8135, 5005, 5008, 5002},
+			err:   "keystone 8112 in secondary style",
+		},
+		{
+			name:  "bad shard order",
+			style: 8000, sub: 8100,
+			perks: []int{8010, 9111, 9104, 8299, 8143, 8135, 5001, 5008, 5002},
+			err:   "invalid shard 5001 in row 0",
+		},
+		{
+			name:  "missing shard",
+			style: 8000, sub: 8100,
+			perks: []int{8010, 9111, 9104, 8299, 8143, 8135, 5005, 5008},
+			err:   "2 shards, expected 3",
+		},
+		{
+			name:  "same styles",
+			style: 8000, sub: 8000,
+			perks: []int{8010, 9111, 9104, 8299, 5005, 5008, 5002},
+			err:   "secondary style is the same as primary style",
+		},
+		{
+			name:  "unknown style",
+			style: 8400, sub: 8100,
+			perks: []int{8437, 8446, 8444, 8451, 8143, 8135, 5005, 5008, 5002},
+			err:   "unknown primary style 8400",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := v.Validate(RuneItem{PrimaryStyleId: tt.style, SubStyleId: tt.sub, SelectedPerkIds: tt.perks})
+			if len(tt.err) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("error %v, expected %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestRuneValidator_DropInvalid(t *testing.T) {
+	v := NewRuneValidator(MakeRuneLookUp(testRunes()))
+	item := ChampionDataItem{
+		Alias:    "Aatrox",
+		Position: "top",
+		Runes: []RuneItem{
+			{Name: "valid", PrimaryStyleId: 8000, SubStyleId: 8100, SelectedPerkIds: []int{8010, 9111, 9104, 8299, 8143, 8135, 5005, 5008, 5002}},
+			{Name: "invalid", PrimaryStyleId: 8000, SubStyleId: 8100, SelectedPerkIds: []int{9111, 9104, 8299, 8143, 8135, 5005, 5008, 5002}},
+		},
+	}
+
+	result := NewResult("test")
+	v.DropInvalid(result, &item)
+	if len(item.Runes) != 1 || item.Runes[0].Name != "valid" {
+		t.Fatalf("runes %+v, expected only the valid page", item.Runes)
+	}
+	if len(result.Rejected) != 1 || !strings.Contains(result.Rejected[0].Reason, "rune page invalid") {
+		t.Fatalf("rejected %+v, expected the invalid page", result.Rejected)
+	}
+}
+
+func TestGetPrimaryIdForRune(t *testing.T) {
+	lookUp, _ := MakeRuneLookUp(testRunes())
+
+	tests := []struct {
+		name     string
+		id       int
+		expected int
+	}{
+		{name: "keystone", id: 8010, expected: 8000},
+		{name: "minor rune", id: 8135, expected: 8100},
+		{name: "unknown", id: 9999, expected: 0},
+		{name: "shard", id: 5008, expected: 0},
+	}
+
+	for _, tt := range tests {
+		if got := GetPrimaryIdForRune(tt.id, lookUp); got != tt.expected {
+			t.Errorf("%s: style %d, expected %d", tt.name, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const DefaultS3Region = `us-east-1`
+
+// S3Sink uploads packages to an S3 compatible object store (AWS S3, MinIO, R2...), with path style
+// requests signed by AWS Signature Version 4.
+type S3Sink struct {
+	endpoint     *url.URL
+	bucket       string
+	prefix       string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+// NewS3Sink creates a sink from `s3://bucket/prefix?endpoint=http://localhost:9000&region=us-east-1`,
+// `endpoint` & `region` default to `AWS_ENDPOINT_URL` & `AWS_REGION`, credentials are read from
+// `AWS_ACCESS_KEY_ID`, `AWS_SECRET_ACCESS_KEY` & `AWS_SESSION_TOKEN`.
+func NewS3Sink(u *url.URL) (*S3Sink, error) {
+	s := S3Sink{
+		bucket:       u.Host,
+		prefix:       strings.Trim(u.Path, "/"),
+		region:       u.Query().Get("region"),
+		accessKey:    os.Getenv("AWS_ACCESS_KEY_ID"),
+		secretKey:    os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		sessionToken: os.Getenv("AWS_SESSION_TOKEN"),
+		client:       &http.Client{Timeout: 2 * time.Minute},
+	}
+	if len(s.bucket) == 0 {
+		return nil, fmt.Errorf("invalid sink: %s, no bucket", u)
+	}
+	if len(s.accessKey) == 0 || len(s.secretKey) == 0 {
+		return nil, fmt.Errorf("sink %s: AWS_ACCESS_KEY_ID & AWS_SECRET_ACCESS_KEY are required", u)
+	}
+	if len(s.region) == 0 {
+		s.region = os.Getenv("AWS_REGION")
+	}
+	if len(s.region) == 0 {
+		s.region = DefaultS3Region
+	}
+
+	endpoint := u.Query().Get("endpoint")
+	if len(endpoint) == 0 {
+		endpoint = os.Getenv("AWS_ENDPOINT_URL")
+	}
+	if len(endpoint) == 0 {
+		endpoint = "https://s3." + s.region + ".amazonaws.com"
+	}
+	var err error
+	if s.endpoint, err = url.Parse(endpoint); err != nil {
+		return nil, err
+	}
+	if s.endpoint.Scheme != "http" && s.endpoint.Scheme != "https" {
+		return nil, fmt.Errorf("invalid sink endpoint: %s", endpoint)
+	}
+
+	return &s, nil
+}
+
+func (s *S3Sink) String() string {
+	return SinkS3 + "://" + path.Join(s.bucket, s.prefix)
+}
+
+// Write uploads all files of the package, `package.json` last, then deletes objects
+// of the previous run which the new one didn't produce.
+func (s *S3Sink) Write(ctx context.Context, pkgName string, dir string) error {
+	files, err := packageFiles(dir)
+	if err != nil {
+		return err
+	}
+	keyPrefix := path.Join(s.prefix, pkgName) + "/"
+	existing, err := s.list(ctx, keyPrefix)
+	if err != nil {
+		return err
+	}
+
+	// `package.json` goes last, so that a new version is only visible once all its files are
+	names := GetKeys(files)
+	sort.Strings(names)
+	sort.SliceStable(names, func(i, j int) bool {
+		return names[j] == "package.json" && names[i] != "package.json"
+	})
+	for _, name := range names {
+		body, err := ioutil.ReadFile(files[name])
+		if err != nil {
+			return err
+		}
+		contentType := mime.TypeByExtension(filepath.Ext(name))
+		if len(contentType) == 0 {
+			contentType = "application/octet-stream"
+		}
+		if _, err = s.do(ctx, http.MethodPut, keyPrefix+name, nil, body, contentType); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range existing {
+		if _, ok := files[strings.TrimPrefix(key, keyPrefix)]; ok {
+			continue
+		}
+		if _, err = s.do(ctx, http.MethodDelete, key, nil, nil, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns keys of all objects starting with `prefix`.
+func (s *S3Sink) list(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	query := url.Values{
+		"list-type": {"2"},
+		"prefix":    {prefix},
+	}
+	for {
+		body, err := s.do(ctx, http.MethodGet, "", query, nil, "")
+		if err != nil {
+			return nil, err
+		}
+
+		var r struct {
+			Contents []struct {
+				Key string `xml:"Key"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		if err = xml.Unmarshal(body, &r); err != nil {
+			return nil, err
+		}
+		for _, c := range r.Contents {
+			keys = append(keys, c.Key)
+		}
+
+		if !r.IsTruncated || len(r.NextContinuationToken) == 0 {
+			return keys, nil
+		}
+		query.Set("continuation-token", r.NextContinuationToken)
+	}
+}
+
+// do sends a signed request of object `key` in the bucket, or the bucket itself if `key` is empty.
+func (s *S3Sink) do(ctx context.Context, method string, key string, query url.Values, body []byte, contentType string) ([]byte, error) {
+	u := strings.TrimSuffix(s.endpoint.String(), "/") + "/" + s3Escape(s.bucket, false)
+	if len(key) > 0 {
+		u += "/" + s3Escape(key, false)
+	}
+	rawQuery := s3Query(query)
+	if len(rawQuery) > 0 {
+		u += "?" + rawQuery
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	// the path as sent, including the path of the endpoint, e.g. a gateway behind `/s3`
+	uri := req.URL.EscapedPath()
+	payloadHash := sha256.Sum256(body)
+	headers := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": hex.EncodeToString(payloadHash[:]),
+		"x-amz-date":           time.Now().UTC().Format(amzDateFormat),
+	}
+	if len(contentType) > 0 {
+		headers["content-type"] = contentType
+	}
+	if len(s.sessionToken) > 0 {
+		headers["x-amz-security-token"] = s.sessionToken
+	}
+	for k, v := range headers {
+		if k != "host" {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("Authorization", s.authorization(method, uri, rawQuery, headers))
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		var e struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		}
+		_ = xml.Unmarshal(resBody, &e)
+		httpErr := &HTTPError{Url: u, StatusCode: res.StatusCode, Status: res.Status}
+		if msg := strings.TrimSpace(e.Code + " " + e.Message); len(msg) > 0 {
+			return nil, fmt.Errorf("%w: %s", httpErr, msg)
+		}
+		return nil, httpErr
+	}
+	return resBody, nil
+}
+
+const amzDateFormat = `20060102T150405Z`
+
+// authorization is the SigV4 `Authorization` header of a request, `headers` are the lower-cased
+// signed headers, which must include `host`, `x-amz-date` & `x-amz-content-sha256`.
+func (s *S3Sink) authorization(method string, uri string, rawQuery string, headers map[string]string) string {
+	names := GetKeys(headers)
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(headers[name]) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		uri,
+		rawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		headers["x-amz-content-sha256"],
+	}, "\n")
+
+	amzDate := headers["x-amz-date"]
+	scope := amzDate[:8] + "/" + s.region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + s.secretKey)
+	for _, v := range []string{amzDate[:8], s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, v)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	return "AWS4-HMAC-SHA256 Credential=" + s.accessKey + "/" + scope +
+		", SignedHeaders=" + signedHeaders + ", Signature=" + signature
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape URI-encodes `s` as SigV4 requires, everything but unreserved characters (and `/` in keys).
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !escapeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Query is the canonical query string, sorted by key.
+func s3Query(query url.Values) string {
+	keys := GetKeys(query)
+	sort.Strings(keys)
+	var pairs []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
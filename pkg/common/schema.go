@@ -0,0 +1,208 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// SchemaVersion is the version of the package format, bump it on any incompatible change
+// of `ChampionDataItem`, `ItemBuild` or `RuneItem`.
+const SchemaVersion = 1
+
+const SchemaFile = `schema.json`
+const jsonSchemaDraft = `http://json-schema.org/draft-07/schema#`
+
+// Schema is the subset of JSON Schema generated from Go types.
+type Schema struct {
+	Draft                string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Version              int                `json:"version,omitempty"`
+	Type                 []string           `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+}
+
+// ChampionFileSchema describes `<alias>.json` of a package, a list of `ChampionDataItem`.
+func ChampionFileSchema() *Schema {
+	s := NewSchema(reflect.TypeOf([]ChampionDataItem{}))
+	s.Draft = jsonSchemaDraft
+	s.Title = `champion`
+	s.Version = SchemaVersion
+	return s
+}
+
+// NewSchema generates the schema of values of type `t` marshalled by `encoding/json`.
+func NewSchema(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := NewSchema(t.Elem())
+		s.Type = append(s.Type, "null")
+		return s
+	case reflect.Bool:
+		return &Schema{Type: []string{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: []string{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: []string{"number"}}
+	case reflect.String:
+		return &Schema{Type: []string{"string"}}
+	case reflect.Slice, reflect.Array:
+		// nil slices are marshalled as `null`
+		return &Schema{Type: []string{"array", "null"}, Items: NewSchema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: []string{"object", "null"}, AdditionalProperties: NewSchema(t.Elem())}
+	case reflect.Struct:
+		s := Schema{
+			Type:                 []string{"object"},
+			Properties:           make(map[string]*Schema),
+			AdditionalProperties: false,
+		}
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" {
+				continue
+			}
+			name, omitEmpty := jsonName(f)
+			if name == "-" {
+				continue
+			}
+			s.Properties[name] = NewSchema(f.Type)
+			if !omitEmpty {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return &s
+	}
+
+	// anything
+	return &Schema{}
+}
+
+func jsonName(f reflect.StructField) (string, bool) {
+	parts := strings.Split(f.Tag.Get("json"), ",")
+	name := parts[0]
+	if len(name) == 0 {
+		name = f.Name
+	}
+	return name, Includes("omitempty", parts[1:])
+}
+
+// Validate checks decoded JSON `v` against the schema, and returns an error for each mismatch,
+// prefixed by the path of the value, e.g. `[0].runes[1].selectedPerkIds`.
+func (s *Schema) Validate(path string, v interface{}) []error {
+	if len(s.Type) > 0 && !Includes(jsonType(v), s.Type) &&
+		!(jsonType(v) == "integer" && Includes("number", s.Type)) {
+		return []error{fmt.Errorf("%s: expected %s, got %s", path, strings.Join(s.Type, " or "), jsonType(v))}
+	}
+
+	var errs []error
+	switch val := v.(type) {
+	case []interface{}:
+		if s.Items != nil {
+			for i, item := range val {
+				errs = append(errs, s.Items.Validate(fmt.Sprintf("%s[%d]", path, i), item)...)
+			}
+		}
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := val[name]; !ok {
+				errs = append(errs, fmt.Errorf("%s: missing property %s", path, name))
+			}
+		}
+
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if p, ok := s.Properties[k]; ok {
+				errs = append(errs, p.Validate(path+"."+k, val[k])...)
+				continue
+			}
+			switch additional := s.AdditionalProperties.(type) {
+			case bool:
+				if !additional {
+					errs = append(errs, fmt.Errorf("%s: unknown property %s", path, k))
+				}
+			case *Schema:
+				errs = append(errs, additional.Validate(path+"."+k, val[k])...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func jsonType(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if val == float64(int64(val)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// ValidatePackage checks `package.json` & all champion files of the package in `dir`.
+func ValidatePackage(dir string) []error {
+	var pkg struct {
+		SchemaVersion int `json:"schemaVersion"`
+	}
+	body, err := ioutil.ReadFile(filepath.Join(dir, "package.json"))
+	if err == nil {
+		err = json.Unmarshal(body, &pkg)
+	}
+	if err != nil {
+		return []error{err}
+	}
+	if pkg.SchemaVersion != SchemaVersion {
+		return []error{fmt.Errorf("%s: schema version %d, expected %d", dir, pkg.SchemaVersion, SchemaVersion)}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	schema := ChampionFileSchema()
+	for _, file := range files {
+		name := filepath.Base(file)
+		if name == "package.json" || name == SchemaFile || name == IndexFile {
+			continue
+		}
+
+		var v interface{}
+		body, err := ioutil.ReadFile(file)
+		if err == nil {
+			err = json.Unmarshal(body, &v)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", file, err))
+			continue
+		}
+		errs = append(errs, schema.Validate(file, v)...)
+	}
+
+	return errs
+}
@@ -0,0 +1,248 @@
+package common
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SinkDir    = `dir`
+	SinkTarGz  = `tar.gz`
+	SinkZip    = `zip`
+	SinkS3     = `s3`
+	SinkSQLite = `sqlite`
+)
+
+// Sink receives packages once they are written & validated in their staging dir.
+type Sink interface {
+	// Write copies the package staged in `dir` to the sink as `pkgName`, replacing the previous one.
+	Write(ctx context.Context, pkgName string, dir string) error
+	String() string
+}
+
+// ParseSink creates a sink from `spec`, a dir (`output` or `dir:output`) for `output/<pkg>`,
+// `tar.gz:dist` or `zip:dist` for archives like `dist/<pkg>.zip`, `sqlite:builds.db`,
+// or `s3://bucket/prefix`, see `NewS3Sink`.
+func ParseSink(spec string) (Sink, error) {
+	if strings.HasPrefix(spec, SinkS3+"://") {
+		u, err := url.Parse(spec)
+		if err != nil {
+			return nil, err
+		}
+		return NewS3Sink(u)
+	}
+
+	kind, dir := SinkDir, spec
+	if kv := strings.SplitN(spec, ":", 2); len(kv) == 2 {
+		kind, dir = kv[0], kv[1]
+	}
+	if len(dir) == 0 {
+		return nil, fmt.Errorf("invalid sink: %s", spec)
+	}
+
+	switch kind {
+	case SinkDir:
+		return &DirSink{Root: dir}, nil
+	case SinkTarGz, SinkZip:
+		return &ArchiveSink{Root: dir, Format: kind}, nil
+	case SinkSQLite:
+		return NewSQLiteSink(dir)
+	default:
+		return nil, fmt.Errorf("invalid sink: %s", spec)
+	}
+}
+
+// ParseSinks parses comma separated sink specs.
+func ParseSinks(specs string) ([]Sink, error) {
+	var sinks []Sink
+	for _, spec := range strings.Split(specs, ",") {
+		if spec = strings.TrimSpace(spec); len(spec) == 0 {
+			continue
+		}
+
+		s, err := ParseSink(spec)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 0 {
+		return nil, fmt.Errorf("no sink")
+	}
+	return sinks, nil
+}
+
+// CloseSinks releases sinks holding resources across packages, e.g. a database, once all are written.
+func CloseSinks(sinks []Sink) error {
+	var errs []string
+	for _, s := range sinks {
+		if c, ok := s.(io.Closer); ok {
+			if err := c.Close(); err != nil {
+				errs = append(errs, s.String()+": "+err.Error())
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("close sinks failed: " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// packageFiles lists files of the package in `dir`, keyed by name.
+func packageFiles(dir string) (map[string]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, e := range entries {
+		if e.Mode().IsRegular() {
+			files[e.Name()] = filepath.Join(dir, e.Name())
+		}
+	}
+	return files, nil
+}
+
+// DirSink writes each package as `<Root>/<pkg>`, champion files of the previous run which the new one
+// didn't produce are removed with the previous dir.
+//
+// The swap takes two renames, so `<Root>/<pkg>` is briefly missing, but never half written.
+// If both the second rename and the rollback fail, the previous package is left in `<Root>/.<pkg>.old`,
+// and restored by the next write.
+type DirSink struct {
+	Root string
+}
+
+func (s *DirSink) String() string {
+	return SinkDir + ":" + s.Root
+}
+
+func (s *DirSink) Write(_ context.Context, pkgName string, dir string) error {
+	files, err := packageFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	target := filepath.Join(s.Root, pkgName)
+	tmp := filepath.Join(s.Root, "."+pkgName+".tmp")
+	old := filepath.Join(s.Root, "."+pkgName+".old")
+	// a previous swap failed halfway, `old` is the only copy of the previous package
+	if _, err = os.Stat(target); os.IsNotExist(err) {
+		if _, err = os.Stat(old); err == nil {
+			if err = os.Rename(old, target); err != nil {
+				return err
+			}
+		}
+	}
+	if err = os.RemoveAll(tmp); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+	if err = os.MkdirAll(tmp, os.ModePerm); err != nil {
+		return err
+	}
+	for name, file := range files {
+		body, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if err = ioutil.WriteFile(filepath.Join(tmp, name), body, 0644); err != nil {
+			return err
+		}
+	}
+
+	// swap dirs, the previous one is restored if the swap fails
+	if err = os.RemoveAll(old); err != nil {
+		return err
+	}
+	hasOld := true
+	if err = os.Rename(target, old); os.IsNotExist(err) {
+		hasOld = false
+	} else if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, target); err != nil {
+		if hasOld {
+			_ = os.Rename(old, target)
+		}
+		return err
+	}
+
+	return os.RemoveAll(old)
+}
+
+// ArchiveSink writes each package as a single `<Root>/<pkg>.tar.gz` or `<Root>/<pkg>.zip`,
+// with files under `<pkg>/`. Archives of the same content are identical.
+type ArchiveSink struct {
+	Root   string
+	Format string
+}
+
+func (s *ArchiveSink) String() string {
+	return s.Format + ":" + s.Root
+}
+
+func (s *ArchiveSink) Write(_ context.Context, pkgName string, dir string) error {
+	files, err := packageFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if s.Format == SinkZip {
+		data, err = makeZip(files, pkgName+"/")
+	} else {
+		data, err = makeTarball(files, pkgName+"/")
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(filepath.Join(s.Root, pkgName+"."+s.Format), data)
+}
+
+// makeZip zips `files`, keyed by their name in the archive, in a stable order.
+func makeZip(files map[string]string, prefix string) ([]byte, error) {
+	names := GetKeys(files)
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		body, err := ioutil.ReadFile(files[name])
+		if err != nil {
+			return nil, err
+		}
+
+		h := &zip.FileHeader{
+			Name:     prefix + name,
+			Method:   zip.Deflate,
+			Modified: packMtime,
+		}
+		h.SetMode(0644)
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package common
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestDirSink_Write(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous func(root string)
+	}{
+		{name: "first write", previous: func(root string) {}},
+		{name: "replace", previous: func(root string) {
+			writeFiles(t, filepath.Join(root, "op.gg"), "package.json", "Zed.json", "Yone.json")
+		}},
+		{name: "failed swap", previous: func(root string) {
+			writeFiles(t, filepath.Join(root, ".op.gg.old"), "package.json", "Zed.json", "Yone.json")
+			writeFiles(t, filepath.Join(root, ".op.gg.tmp"), "package.json")
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := ioutil.TempDir("", "sink")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(root)
+			tt.previous(root)
+			staging := filepath.Join(root, ".staging", "op.gg")
+			writeFiles(t, staging, "package.json", "Zed.json")
+
+			if err = (&DirSink{Root: root}).Write(context.Background(), "op.gg", staging); err != nil {
+				t.Fatal(err)
+			}
+			if got := listFiles(t, filepath.Join(root, "op.gg")); !reflect.DeepEqual(got, []string{"Zed.json", "package.json"}) {
+				t.Fatalf("files %v, expected only files of the new package", got)
+			}
+			if got := listFiles(t, root); !reflect.DeepEqual(got, []string{".staging", "op.gg"}) {
+				t.Fatalf("dirs %v, expected no leftovers of the swap", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+type Mode string
+
+const (
+	ModeClassic Mode = `classic`
+	ModeAram    Mode = `aram`
+)
+
+// Deps holds everything shared between sources for a single crawl.
+type Deps struct {
+	Champions       map[string]ChampionItem
+	AliasList       map[string]string
+	OfficialVersion string
+	Timestamp       int64
+	RuneLookUp      IRuneLookUp
+	AllRunes        IAllRunes
+	Items           *map[string]BuildItem
+	Fetcher         *Fetcher
+	DataDragon      *DataDragon
+	// Localized is nil unless several locales are requested.
+	Localized *Localized
+	// Concurrency is the maximum jobs a source runs at once.
+	Concurrency int
+	Logger      *Logger
+	Debug       bool
+}
+
+// Source is a data provider, which generates one output package.
+type Source interface {
+	// Name is the provider name used to select sources from the command line,
+	// several sources may share one name (e.g. `opgg` for both op.gg & op.gg-aram).
+	Name() string
+	// PkgName is the unique name of the generated package.
+	PkgName() string
+	Modes() []Mode
+	Fetch(ctx context.Context, deps *Deps) (*Result, error)
+}
+
+var registry []Source
+
+// RegisterSource adds a source to the registry, it's expected to be called from `init()`.
+func RegisterSource(s Source) {
+	for _, v := range registry {
+		if v.PkgName() == s.PkgName() {
+			panic(fmt.Sprintf("source %s already registered", s.PkgName()))
+		}
+	}
+
+	registry = append(registry, s)
+}
+
+// Sources returns all registered sources, sorted by package name.
+func Sources() []Source {
+	list := make([]Source, len(registry))
+	copy(list, registry)
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].PkgName() < list[j].PkgName()
+	})
+	return list
+}
+
+func HasMode(s Source, mode Mode) bool {
+	for _, m := range s.Modes() {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SelectSources picks registered sources matching any of `names` (by name or package name),
+// an empty `names` matches all. If `mode` is not empty, only sources supporting it are kept.
+func SelectSources(names []string, mode Mode) ([]Source, error) {
+	var selected []Source
+	matched := make(map[string]bool)
+
+	for _, s := range Sources() {
+		if len(mode) > 0 && !HasMode(s, mode) {
+			continue
+		}
+
+		hit := len(names) == 0
+		for _, n := range names {
+			if n == s.Name() || n == s.PkgName() {
+				matched[n] = true
+				hit = true
+			}
+		}
+
+		if hit {
+			selected = append(selected, s)
+		}
+	}
+
+	for _, n := range names {
+		if !matched[n] && !sourceExists(n) {
+			return nil, fmt.Errorf("unknown source: %s", n)
+		}
+	}
+
+	return selected, nil
+}
+
+func sourceExists(name string) bool {
+	for _, s := range registry {
+		if name == s.Name() || name == s.PkgName() {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+//go:build cgo
+// +build cgo
+
+package common
+
+import (
+	"context"
+	"database/sql"
+	_ "github.com/mattn/go-sqlite3"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sqliteSchema normalizes champion files, a source is a package crawled for a patch,
+// e.g. `op.gg` of `11.14.1`, crawling the same patch again replaces its rows.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sources (
+	id             INTEGER PRIMARY KEY,
+	name           TEXT NOT NULL,
+	patch          TEXT NOT NULL,
+	source_version TEXT NOT NULL,
+	content_hash   TEXT NOT NULL,
+	crawled_at     TEXT NOT NULL,
+	UNIQUE (name, patch)
+);
+CREATE TABLE IF NOT EXISTS champions (
+	id        INTEGER PRIMARY KEY,
+	source_id INTEGER NOT NULL REFERENCES sources (id) ON DELETE CASCADE,
+	alias     TEXT NOT NULL,
+	name      TEXT NOT NULL,
+	UNIQUE (source_id, alias)
+);
+CREATE TABLE IF NOT EXISTS positions (
+	id          INTEGER PRIMARY KEY,
+	champion_id INTEGER NOT NULL REFERENCES champions (id) ON DELETE CASCADE,
+	position    TEXT NOT NULL,
+	skills      TEXT NOT NULL,
+	spells      TEXT NOT NULL,
+	UNIQUE (champion_id, position)
+);
+CREATE TABLE IF NOT EXISTS item_builds (
+	id          INTEGER PRIMARY KEY,
+	position_id INTEGER NOT NULL REFERENCES positions (id) ON DELETE CASCADE,
+	idx         INTEGER NOT NULL,
+	title       TEXT NOT NULL,
+	map         TEXT NOT NULL,
+	mode        TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS blocks (
+	id            INTEGER PRIMARY KEY,
+	item_build_id INTEGER NOT NULL REFERENCES item_builds (id) ON DELETE CASCADE,
+	idx           INTEGER NOT NULL,
+	type          TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS block_items (
+	id       INTEGER PRIMARY KEY,
+	block_id INTEGER NOT NULL REFERENCES blocks (id) ON DELETE CASCADE,
+	idx      INTEGER NOT NULL,
+	item_id  TEXT NOT NULL,
+	count    INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS rune_pages (
+	id               INTEGER PRIMARY KEY,
+	position_id      INTEGER NOT NULL REFERENCES positions (id) ON DELETE CASCADE,
+	idx              INTEGER NOT NULL,
+	name             TEXT NOT NULL,
+	primary_style_id INTEGER NOT NULL,
+	sub_style_id     INTEGER NOT NULL,
+	pick_count       INTEGER NOT NULL,
+	win_rate         TEXT NOT NULL,
+	score            REAL NOT NULL
+);
+CREATE TABLE IF NOT EXISTS perks (
+	id           INTEGER PRIMARY KEY,
+	rune_page_id INTEGER NOT NULL REFERENCES rune_pages (id) ON DELETE CASCADE,
+	idx          INTEGER NOT NULL,
+	perk_id      INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS champions_source_id ON champions (source_id);
+CREATE INDEX IF NOT EXISTS positions_champion_id ON positions (champion_id);
+CREATE INDEX IF NOT EXISTS item_builds_position_id ON item_builds (position_id);
+CREATE INDEX IF NOT EXISTS blocks_item_build_id ON blocks (item_build_id);
+CREATE INDEX IF NOT EXISTS block_items_block_id ON block_items (block_id);
+CREATE INDEX IF NOT EXISTS block_items_item_id ON block_items (item_id);
+CREATE INDEX IF NOT EXISTS rune_pages_position_id ON rune_pages (position_id);
+CREATE INDEX IF NOT EXISTS perks_rune_page_id ON perks (rune_page_id);
+CREATE INDEX IF NOT EXISTS perks_perk_id ON perks (perk_id);
+`
+
+// SQLiteSink exports packages into normalized tables of a SQLite database, so builds of all sources
+// can be queried with SQL. Each package is written in a single transaction.
+type SQLiteSink struct {
+	File string
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// NewSQLiteSink creates a sink exporting into database `file`, the database is opened on first write.
+func NewSQLiteSink(file string) (Sink, error) {
+	return &SQLiteSink{File: file}, nil
+}
+
+func (s *SQLiteSink) String() string {
+	return SinkSQLite + ":" + s.File
+}
+
+// open creates the database & tables on first use, as sources finish concurrently.
+func (s *SQLiteSink) open() (*sql.DB, error) {
+	s.once.Do(func() {
+		s.db, s.err = sql.Open("sqlite3", "file:"+s.File+"?_foreign_keys=on&_busy_timeout=5000")
+		if s.err != nil {
+			return
+		}
+		// a single connection serializes writes of packages
+		s.db.SetMaxOpenConns(1)
+		_, s.err = s.db.Exec(sqliteSchema)
+	})
+	return s.db, s.err
+}
+
+// Close closes the database once all packages are written.
+func (s *SQLiteSink) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *SQLiteSink) Write(ctx context.Context, pkgName string, dir string) error {
+	pkg, err := LoadPackage(dir)
+	if err != nil {
+		return err
+	}
+	db, err := s.open()
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if err = writeSQLitePackage(ctx, tx, pkgName, pkg); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func writeSQLitePackage(ctx context.Context, tx *sql.Tx, pkgName string, pkg *Package) error {
+	patch := pkg.Info.DataDragonVersion
+	if _, err := tx.ExecContext(ctx, `DELETE FROM sources WHERE name = ? AND patch = ?`, pkgName, patch); err != nil {
+		return err
+	}
+	sourceId, err := insert(ctx, tx, `INSERT INTO sources (name, patch, source_version, content_hash, crawled_at) VALUES (?, ?, ?, ?, ?)`,
+		pkgName, patch, pkg.Info.SourceVersion, pkg.Info.ContentHash, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+
+	aliases := GetKeys(pkg.Champions)
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		items := pkg.Champions[alias]
+		name := alias
+		if len(items) > 0 && len(items[0].Name) > 0 {
+			name = items[0].Name
+		}
+		championId, err := insert(ctx, tx, `INSERT INTO champions (source_id, alias, name) VALUES (?, ?, ?)`, sourceId, alias, name)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if err = writeSQLitePosition(ctx, tx, championId, item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func writeSQLitePosition(ctx context.Context, tx *sql.Tx, championId int64, item ChampionDataItem) error {
+	positionId, err := insert(ctx, tx, `INSERT INTO positions (champion_id, position, skills, spells) VALUES (?, ?, ?, ?)`,
+		championId, item.Position, strings.Join(item.Skills, ","), strings.Join(item.Spells, ","))
+	if err != nil {
+		return err
+	}
+
+	for i, build := range item.ItemBuilds {
+		buildId, err := insert(ctx, tx, `INSERT INTO item_builds (position_id, idx, title, map, mode) VALUES (?, ?, ?, ?, ?)`,
+			positionId, i, build.Title, build.Map, build.Mode)
+		if err != nil {
+			return err
+		}
+
+		for j, block := range build.Blocks {
+			blockId, err := insert(ctx, tx, `INSERT INTO blocks (item_build_id, idx, type) VALUES (?, ?, ?)`, buildId, j, block.Type)
+			if err != nil {
+				return err
+			}
+			for k, b := range block.Items {
+				if _, err = insert(ctx, tx, `INSERT INTO block_items (block_id, idx, item_id, count) VALUES (?, ?, ?, ?)`, blockId, k, b.Id, b.Count); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for i, page := range item.Runes {
+		pageId, err := insert(ctx, tx, `INSERT INTO rune_pages (position_id, idx, name, primary_style_id, sub_style_id, pick_count, win_rate, score) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			positionId, i, page.Name, page.PrimaryStyleId, page.SubStyleId, page.PickCount, page.WinRate, page.Score)
+		if err != nil {
+			return err
+		}
+
+		for j, perk := range page.SelectedPerkIds {
+			if _, err = insert(ctx, tx, `INSERT INTO perks (rune_page_id, idx, perk_id) VALUES (?, ?, ?)`, pageId, j, perk); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func insert(ctx context.Context, tx *sql.Tx, query string, args ...interface{}) (int64, error) {
+	res, err := tx.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
@@ -0,0 +1,11 @@
+//go:build !cgo
+// +build !cgo
+
+package common
+
+import "fmt"
+
+// NewSQLiteSink fails without cgo, which the SQLite driver requires.
+func NewSQLiteSink(file string) (Sink, error) {
+	return nil, fmt.Errorf("sink %s:%s: SQLite requires a build with cgo (CGO_ENABLED=1)", SinkSQLite, file)
+}
@@ -8,6 +8,8 @@ type BlockItem struct {
 type ItemBuildBlockItem struct {
 	Type  string      `json:"type"`
 	Items []BlockItem `json:"items"`
+	// Types is the localized `Type`, keyed by locale
+	Types map[string]string `json:"types,omitempty"`
 }
 
 type ItemBuild struct {
@@ -21,6 +23,8 @@ type ItemBuild struct {
 	Sortrank            int                  `json:"sortrank"`
 	StartedFrom         string               `json:"startedFrom"`
 	Type                string               `json:"type"`
+	// Titles is the localized `Title`, keyed by locale
+	Titles map[string]string `json:"titles,omitempty"`
 }
 
 type RuneItem struct {
@@ -33,21 +37,24 @@ type RuneItem struct {
 	SubStyleId      int     `json:"subStyleId"`
 	SelectedPerkIds []int   `json:"selectedPerkIds"`
 	Score           float64 `json:"score"`
+	// Names is the localized `Name`, keyed by locale
+	Names map[string]string `json:"names,omitempty"`
 }
 
 type ChampionDataItem struct {
-	Index           int         `json:"index"`
-	Id              string      `json:"id"`
-	Version         string      `json:"version"`
-	OfficialVersion string      `json:"officialVersion"`
-	Timestamp       int64       `json:"timestamp"`
-	Alias           string      `json:"alias"`
-	Name            string      `json:"name"`
-	Position        string      `json:"position"`
-	Skills          []string    `json:"skills"`
-	Spells          []string    `json:"spells"`
-	ItemBuilds      []ItemBuild `json:"itemBuilds"`
-	Runes           []RuneItem  `json:"runes"`
+	Index           int               `json:"index"`
+	Id              string            `json:"id"`
+	Version         string            `json:"version"`
+	OfficialVersion string            `json:"officialVersion"`
+	Timestamp       int64             `json:"timestamp"`
+	Alias           string            `json:"alias"`
+	Name            string            `json:"name"`
+	Names           map[string]string `json:"names,omitempty"`
+	Position        string            `json:"position"`
+	Skills          []string          `json:"skills"`
+	Spells          []string          `json:"spells"`
+	ItemBuilds      []ItemBuild       `json:"itemBuilds"`
+	Runes           []RuneItem        `json:"runes"`
 }
 
 type ChampionItem struct {
@@ -57,47 +64,52 @@ type ChampionItem struct {
 	Name    string `json:"name"`
 	Title   string `json:"title"`
 	Blurb   string `json:"blurb"`
-	Info    struct {
+	// Names & Titles are filled from all locales, keyed by locale
+	Names  map[string]string `json:"names,omitempty"`
+	Titles map[string]string `json:"titles,omitempty"`
+	Info   struct {
 		Attack     int `json:"attack"`
 		Defense    int `json:"defense"`
 		Magic      int `json:"magic"`
 		Difficulty int `json:"difficulty"`
 	} `json:"info"`
-	Image struct {
-		Full   string `json:"full"`
-		Sprite string `json:"sprite"`
-		Group  string `json:"group"`
-		X      int    `json:"x"`
-		Y      int    `json:"y"`
-		W      int    `json:"w"`
-		H      int    `json:"h"`
-	} `json:"image"`
-	Tags    []string `json:"tags"`
-	Partype string   `json:"partype"`
+	Image   ImageInfo `json:"image"`
+	Tags    []string  `json:"tags"`
+	Partype string    `json:"partype"`
 	Stats   struct {
-		Hp                   int     `json:"hp"`
-		Hpperlevel           int     `json:"hpperlevel"`
-		Mp                   int     `json:"mp"`
-		Mpperlevel           int     `json:"mpperlevel"`
-		Movespeed            int     `json:"movespeed"`
-		Armor                int     `json:"armor"`
-		Armorperlevel        int     `json:"armorperlevel"`
-		Spellblock           int     `json:"spellblock"`
-		Spellblockperlevel   int     `json:"spellblockperlevel"`
-		Attackrange          int     `json:"attackrange"`
-		Hpregen              int     `json:"hpregen"`
-		Hpregenperlevel      int     `json:"hpregenperlevel"`
-		Mpregen              int     `json:"mpregen"`
-		Mpregenperlevel      int     `json:"mpregenperlevel"`
-		Crit                 int     `json:"crit"`
-		Critperlevel         int     `json:"critperlevel"`
-		Attackdamage         int     `json:"attackdamage"`
-		Attackdamageperlevel int     `json:"attackdamageperlevel"`
-		Attackspeedperlevel  float32 `json:"attackspeedperlevel"`
-		Attackspeed          float32 `json:"attackspeed"`
+		Hp                   float64 `json:"hp"`
+		Hpperlevel           float64 `json:"hpperlevel"`
+		Mp                   float64 `json:"mp"`
+		Mpperlevel           float64 `json:"mpperlevel"`
+		Movespeed            float64 `json:"movespeed"`
+		Armor                float64 `json:"armor"`
+		Armorperlevel        float64 `json:"armorperlevel"`
+		Spellblock           float64 `json:"spellblock"`
+		Spellblockperlevel   float64 `json:"spellblockperlevel"`
+		Attackrange          float64 `json:"attackrange"`
+		Hpregen              float64 `json:"hpregen"`
+		Hpregenperlevel      float64 `json:"hpregenperlevel"`
+		Mpregen              float64 `json:"mpregen"`
+		Mpregenperlevel      float64 `json:"mpregenperlevel"`
+		Crit                 float64 `json:"crit"`
+		Critperlevel         float64 `json:"critperlevel"`
+		Attackdamage         float64 `json:"attackdamage"`
+		Attackdamageperlevel float64 `json:"attackdamageperlevel"`
+		Attackspeedperlevel  float64 `json:"attackspeedperlevel"`
+		Attackspeed          float64 `json:"attackspeed"`
 	} `json:"stats"`
 }
 
+type ImageInfo struct {
+	Full   string `json:"full"`
+	Sprite string `json:"sprite"`
+	Group  string `json:"group"`
+	X      int    `json:"x"`
+	Y      int    `json:"y"`
+	W      int    `json:"w"`
+	H      int    `json:"h"`
+}
+
 type ChampionListResp struct {
 	Type    string                  `json:"type"`
 	Format  string                  `json:"format"`
@@ -106,33 +118,57 @@ type ChampionListResp struct {
 }
 
 type PkgInfo struct {
-	PkgName         string `json:"pkgName"`
-	Timestamp       int64  `json:"timestamp"`
-	SourceVersion   string `json:"sourceVersion"`
-	OfficialVersion string `json:"officialVersion"`
+	PkgName           string `json:"pkgName"`
+	Timestamp         int64  `json:"timestamp"`
+	SourceVersion     string `json:"sourceVersion"`
+	OfficialVersion   string `json:"officialVersion"`
+	DataDragonVersion string `json:"dataDragonVersion"`
+	SchemaVersion     int    `json:"schemaVersion"`
+	ContentHash       string `json:"contentHash"`
 }
 
 type BuildItem struct {
-	Name        string          `json:"name"`
-	Description string          `json:"description"`
-	Colloq      string          `json:"colloq"`
-	Plaintext   string          `json:"plaintext"`
-	From        []string        `json:"from"`
-	Into        []string        `json:"into"`
-	Image       string          `json:"image"`
-	Gold        string          `json:"gold"`
-	Tags        []string        `json:"tags"`
-	Maps        map[string]bool `json:"maps"`
-	Stats       string          `json:"stats"`
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Colloq      string             `json:"colloq"`
+	Plaintext   string             `json:"plaintext"`
+	From        []string           `json:"from"`
+	Into        []string           `json:"into"`
+	Image       ImageInfo          `json:"image"`
+	Gold        ItemGold           `json:"gold"`
+	Tags        []string           `json:"tags"`
+	Maps        map[string]bool    `json:"maps"`
+	Stats       map[string]float64 `json:"stats"`
+}
+
+type ItemGold struct {
+	Base        int  `json:"base"`
+	Total       int  `json:"total"`
+	Sell        int  `json:"sell"`
+	Purchasable bool `json:"purchasable"`
 }
 
 type BuildItemResp struct {
 	Type    string               `json:"type"`
 	Version string               `json:"version"`
-	Basic   string               `json:"basic"`
+	Basic   BuildItem            `json:"basic"`
 	Data    map[string]BuildItem `json:"data"`
 }
 
+type SummonerSpell struct {
+	Id          string   `json:"id"`
+	Key         string   `json:"key"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Modes       []string `json:"modes"`
+}
+
+type SummonerSpellResp struct {
+	Type    string                   `json:"type"`
+	Version string                   `json:"version"`
+	Data    map[string]SummonerSpell `json:"data"`
+}
+
 type RespRuneItem struct {
 	Id        int    `json:"id"`
 	Key       string `json:"key"`
@@ -156,4 +192,4 @@ type RuneSlot struct {
 }
 
 type IRuneLookUp map[int]*RespRuneItem
-type IAllRunes *[]RuneSlot
\ No newline at end of file
+type IAllRunes *[]RuneSlot
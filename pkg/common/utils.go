@@ -3,12 +3,8 @@ package common
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
-	"fmt"
-	"github.com/PuerkitoBio/goquery"
 	"html/template"
 	"io/ioutil"
-	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -16,7 +12,6 @@ import (
 	"strings"
 )
 
-const DataDragonUrl = "https://ddragon.leagueoflegends.com"
 const BaseBootId = `1001`
 
 func MatchSpellName(src string) string {
@@ -63,43 +58,6 @@ func Includes(target string, list []string) bool {
 	return existed
 }
 
-func MakeRequest(url string) ([]byte, error) {
-	res, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-
-	defer res.Body.Close()
-	if res.StatusCode != 200 {
-		return nil, errors.New(res.Status)
-	}
-
-	body, _ := ioutil.ReadAll(res.Body)
-	return body, nil
-}
-
-func GetChampionList() (*ChampionListResp, string, error) {
-	body, err := MakeRequest(DataDragonUrl + "/api/versions.json")
-	if err != nil {
-		return nil, "", err
-	}
-
-	var versionArr []string
-	_ = json.Unmarshal(body, &versionArr)
-	version := versionArr[0]
-
-	cBody, cErr := MakeRequest(DataDragonUrl + "/cdn/" + version + "/data/en_US/champion.json")
-	if cErr != nil {
-		return nil, "", errors.New(`data dragon: request champion list failed`)
-	}
-
-	var resp ChampionListResp
-	_ = json.Unmarshal(cBody, &resp)
-
-	fmt.Printf("🤖 Got official champion list, total %d \n", len(resp.Data))
-	return &resp, version, nil
-}
-
 func SaveJSON(fileName string, data interface{}) error {
 	file, _ := json.MarshalIndent(data, "", "  ")
 	wErr := ioutil.WriteFile(fileName, file, 0644)
@@ -111,17 +69,6 @@ func SaveJSON(fileName string, data interface{}) error {
 	return nil
 }
 
-func ParseHTML(url string) (*goquery.Document, error) {
-	body, err := MakeRequest(url)
-	if err != nil {
-		return nil, err
-	}
-
-	buf := bytes.NewBuffer(body)
-	reader := ioutil.NopCloser(buf)
-	return goquery.NewDocumentFromReader(reader)
-}
-
 func GenPkgInfo(tplPath string, vars interface{}) (string, error) {
 	tpl, err := template.ParseFiles(tplPath)
 	if err != nil {
@@ -137,17 +84,6 @@ func GenPkgInfo(tplPath string, vars interface{}) (string, error) {
 	return tplBytes.String(), nil
 }
 
-func GetItemList(version string) (*map[string]BuildItem, error) {
-	body, err := MakeRequest(DataDragonUrl + `/cdn/` + version + `/data/en_US/item.json`)
-	if err != nil {
-		return nil, err
-	}
-
-	var resp BuildItemResp
-	_ = json.Unmarshal(body, &resp)
-	return &resp.Data, nil
-}
-
 func IsBoot(id string, items map[string]BuildItem) bool {
 	result := Includes(BaseBootId, items[id].From)
 	return result
@@ -168,31 +104,6 @@ func MakeBuildBlock(arr []string, name string) ItemBuildBlockItem {
 	return block
 }
 
-func GetRunesReforged(version string) (IRuneLookUp, IAllRunes, error) {
-	body, err := MakeRequest(DataDragonUrl + `/cdn/` + version + `/data/en_US/runesReforged.json`)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	var resp []RuneSlot
-	_ = json.Unmarshal(body, &resp)
-
-	//data := make(map[int]RespRuneItem)
-
-	data := make(map[int]*RespRuneItem)
-	for _, slot := range resp {
-		for j, s := range slot.Slots {
-			for _, r := range s.Runes {
-				r.Style = slot.Id
-				r.Slot = j
-				r.Primary = j == 0
-				data[r.Id] = &r
-			}
-		}
-	}
-	return data, &resp, nil
-}
-
 func GetKeys(v interface{}) []string {
 	var keys []string
 	value := reflect.ValueOf(v)
@@ -207,24 +118,55 @@ func GetKeys(v interface{}) []string {
 	return keys
 }
 
+// GetPrimaryIdForRune returns the style of rune `id`, or 0 for an unknown rune,
+// which the rune validator then rejects.
 func GetPrimaryIdForRune(id int, runeLookUp IRuneLookUp) int {
-	return runeLookUp[id].Style
+	if r, ok := runeLookUp[id]; ok {
+		return r.Style
+	}
+	return 0
 }
 
-func Write2Folder(result [][]ChampionDataItem, pkgName string, timestamp int64, sourceVersion string, officialVer string) {
-	outputPath := filepath.Join(".", "output", pkgName)
-	_ = os.MkdirAll(outputPath, os.ModePerm)
+// Write2Folder writes champion files & `package.json` into the staging dir of `pkgName`.
+func Write2Folder(result [][]ChampionDataItem, pkgName string, timestamp int64, sourceVersion string, officialVer string, ddragonVer string) error {
+	outputPath := StagingDir(pkgName)
+	if err := os.MkdirAll(outputPath, os.ModePerm); err != nil {
+		return err
+	}
 
 	for _, data := range result {
-		fileName := outputPath + "/" + data[0].Alias + ".json"
-		_ = SaveJSON(fileName, data)
+		fileName := filepath.Join(outputPath, data[0].Alias+".json")
+		if err := SaveJSON(fileName, data); err != nil {
+			return err
+		}
 	}
 
-	pkg, _ := GenPkgInfo("tpl/package.json", PkgInfo{
-		Timestamp:       timestamp,
-		SourceVersion:   sourceVersion,
-		OfficialVersion: officialVer,
-		PkgName:         pkgName,
+	return WritePkgInfo(PkgInfo{
+		Timestamp:         timestamp,
+		SourceVersion:     sourceVersion,
+		OfficialVersion:   officialVer,
+		DataDragonVersion: ddragonVer,
+		PkgName:           pkgName,
 	})
-	_ = ioutil.WriteFile("output/"+pkgName+"/package.json", []byte(pkg), 0644)
+}
+
+// WritePkgInfo writes `package.json` & the schema of champion files into the staging dir of the package,
+// it's expected to be called after all champion files are written.
+func WritePkgInfo(info PkgInfo) error {
+	dir := StagingDir(info.PkgName)
+	info.SchemaVersion = SchemaVersion
+	champions, err := loadChampionFiles(dir)
+	if err != nil {
+		return err
+	}
+	info.ContentHash = ContentHash(info, champions)
+
+	pkg, err := GenPkgInfo("tpl/package.json", info)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(filepath.Join(dir, "package.json"), []byte(pkg), 0644); err != nil {
+		return err
+	}
+	return SaveJSON(filepath.Join(dir, SchemaFile), ChampionFileSchema())
 }
@@ -1,14 +1,14 @@
 package lolalytics
 
 import (
+	"context"
 	"data-crawler/pkg/common"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
-	"sync"
-	"time"
 )
 
 var cidReg = regexp.MustCompile("&cid=\\d+?&")
@@ -20,6 +20,9 @@ var patchReg = regexp.MustCompile("&patch=((\\d+\\.)+\\d+?)&")
 const ApiUrl = "https://apix1.op.lol"
 const MinimumPickRate = 5
 
+const PkgName = `lolalytics`
+const AramPkgName = `lolalytics-aram`
+
 func makeQuery(query string) func(string, string, string) string {
 	oldQ := query
 	return func(cid string, lane string, tier string) string {
@@ -35,11 +38,11 @@ func getSourceVersion(q string) string {
 	return m[0][1]
 }
 
-func getTierList(q string) (ITierList, error) {
+func getTierList(ctx context.Context, f *common.Fetcher, q string) (ITierList, error) {
 	var data ITierList
 
 	// list sort by name
-	body, err := common.MakeRequest(ApiUrl + "/tierlist/7/?" + q)
+	body, err := f.MakeRequest(ctx, ApiUrl+"/tierlist/7/?"+q)
 	if err != nil {
 		return data, err
 	}
@@ -87,31 +90,44 @@ func extractItemIds(items []IItemN) []int {
 	return ids
 }
 
-func makeBuildBlocksFromSet(data IItems) []common.ItemBuildBlockItem {
+func makeBuildBlocksFromSet(l *common.Localized, data IItems) []common.ItemBuildBlockItem {
 	var blocks []common.ItemBuildBlockItem
-	startingTitle := "Starting items, win rate " + fmt.Sprintf("%.2f%%", data.Start.Wr)
-	startingBlock := makeBlock(startingTitle, data.Start.Set)
+	winRate := func(locale string, phrase string, wr float64) string {
+		return common.Phrase(locale, phrase) + ", " + common.Phrase(locale, "win rate") + " " + fmt.Sprintf("%.2f%%", wr)
+	}
+
+	startingBlock := makeBlock(winRate(common.DefaultLocale, "Starting items", data.Start.Wr), data.Start.Set)
+	startingBlock.Types = l.Each(func(locale string) string {
+		return winRate(locale, "Starting items", data.Start.Wr)
+	})
 	blocks = append(blocks, startingBlock)
 
-	coreTitle := "Core items, win rate " + fmt.Sprintf("%.2f%%", data.Core.Wr)
-	coreBlock := makeBlock(coreTitle, data.Core.Set)
+	coreBlock := makeBlock(winRate(common.DefaultLocale, "Core items", data.Core.Wr), data.Core.Set)
+	coreBlock.Types = l.Each(func(locale string) string {
+		return winRate(locale, "Core items", data.Core.Wr)
+	})
 	blocks = append(blocks, coreBlock)
 
-	item4Ids := extractItemIds(data.Item4)
-	item4Block := makeBlock("Item 4", item4Ids)
-	blocks = append(blocks, item4Block)
-
-	item5Ids := extractItemIds(data.Item5)
-	item5Block := makeBlock("Item 5", item5Ids)
-	blocks = append(blocks, item5Block)
-
-	item6Ids := extractItemIds(data.Item6)
-	item6Block := makeBlock("Item 6", item6Ids)
-	blocks = append(blocks, item6Block)
+	for i, set := range [][]IItemN{data.Item4, data.Item5, data.Item6} {
+		n := strconv.Itoa(i + 4)
+		block := makeBlock("Item "+n, extractItemIds(set))
+		block.Types = l.Each(func(locale string) string {
+			return common.Phrase(locale, "Item") + " " + n
+		})
+		blocks = append(blocks, block)
+	}
 
 	return blocks
 }
 
+// styleOf returns the style of the first rune of `ids`, or 0 when it's unknown.
+func styleOf(ids []int, lookUp common.IRuneLookUp) int {
+	if len(ids) == 0 {
+		return 0
+	}
+	return common.GetPrimaryIdForRune(ids[0], lookUp)
+}
+
 func concatRuneIds(pri []int, sec []int, mod []int) []int {
 	var ids []int
 	ids = append(ids, pri...)
@@ -120,12 +136,14 @@ func concatRuneIds(pri []int, sec []int, mod []int) []int {
 	return ids
 }
 
-func makeBuild(champion common.ChampionItem, query string, sourceVersion string, officialVer string, timestamp int64, cnt int, fetchMore bool, runeLookUp common.IRuneLookUp, aram bool) (*[]common.ChampionDataItem, error) {
-	body, err := common.MakeRequest(ApiUrl + "/mega?" + query)
+// makeBuild generates builds of the default lane, and of other popular lanes when `fetchMore` is true,
+// failed extra lanes are returned as issues.
+func makeBuild(ctx context.Context, deps *common.Deps, champion common.ChampionItem, query string, sourceVersion string, cnt int, fetchMore bool, aram bool) (*[]common.ChampionDataItem, []common.Issue, error) {
+	body, err := deps.Fetcher.MakeRequest(ctx, ApiUrl+"/mega?"+query)
 
 	if err != nil {
-		fmt.Println("[lolalytics] Fetch champion data failed.", champion.Id)
-		return nil, err
+		deps.Logger.Warn("fetch champion failed", "champion", champion.Id, "error", err)
+		return nil, nil, err
 	}
 
 	var resp IChampionData
@@ -134,12 +152,12 @@ func makeBuild(champion common.ChampionItem, query string, sourceVersion string,
 	curLane := resp.Header.Lane
 
 	if resp.Summary.Sums == nil {
-		errMsg := "[lolalytics] Champion data not ready, " + champion.Name + " " + curLane
-		if aram {
-			errMsg = "[lolalytics-ARAM] Champion data not ready, " + champion.Name + " " + curLane
-		}
-		fmt.Println(errMsg)
-		return nil, errors.New(errMsg)
+		deps.Logger.Warn("champion data not ready", "champion", champion.Id, "position", curLane)
+		return nil, nil, errors.New("champion data not ready, " + champion.Name + " " + curLane)
+	}
+	if len(resp.Summary.Runes.Win.Set.Pri) == 0 || len(resp.Summary.Runes.Win.Set.Sec) == 0 ||
+		len(resp.Summary.Runes.Pick.Set.Pri) == 0 || len(resp.Summary.Runes.Pick.Set.Sec) == 0 {
+		return nil, nil, errors.New("rune data not ready, " + champion.Name + " " + curLane)
 	}
 
 	var builds []common.ChampionDataItem
@@ -148,10 +166,11 @@ func makeBuild(champion common.ChampionItem, query string, sourceVersion string,
 		Index:           cnt,
 		Id:              champion.Key,
 		Version:         sourceVersion,
-		Timestamp:       timestamp,
+		Timestamp:       deps.Timestamp,
 		Alias:           champion.Id,
 		Name:            champion.Name,
-		OfficialVersion: officialVer,
+		Names:           champion.Names,
+		OfficialVersion: deps.OfficialVersion,
 	}
 
 	buildTitlePrefix := "[lolalytics]"
@@ -172,7 +191,10 @@ func makeBuild(champion common.ChampionItem, query string, sourceVersion string,
 		Sortrank:            1,
 		StartedFrom:         "blank",
 		Type:                "custom",
-		Blocks:              makeBuildBlocksFromSet(resp.Summary.Items.Win),
+		Blocks:              makeBuildBlocksFromSet(deps.Localized, resp.Summary.Items.Win),
+		Titles: deps.Localized.FormatIn(champion.Id, func(locale string, name string) string {
+			return buildTitlePrefix + " " + name + " " + common.Phrase(locale, "Highest Win") + buildTitleSuffix
+		}),
 	}
 	defaultBuild.ItemBuilds = append(defaultBuild.ItemBuilds, highestWinBuild)
 	mostCommonBuild := common.ItemBuild{
@@ -185,7 +207,10 @@ func makeBuild(champion common.ChampionItem, query string, sourceVersion string,
 		Sortrank:            1,
 		StartedFrom:         "blank",
 		Type:                "custom",
-		Blocks:              makeBuildBlocksFromSet(resp.Summary.Items.Pick),
+		Blocks:              makeBuildBlocksFromSet(deps.Localized, resp.Summary.Items.Pick),
+		Titles: deps.Localized.FormatIn(champion.Id, func(locale string, name string) string {
+			return buildTitlePrefix + " " + name + " " + common.Phrase(locale, "Most Common") + buildTitleSuffix
+		}),
 	}
 	defaultBuild.ItemBuilds = append(defaultBuild.ItemBuilds, mostCommonBuild)
 
@@ -196,30 +221,37 @@ func makeBuild(champion common.ChampionItem, query string, sourceVersion string,
 		runeTitleSuffix = ", " + sourceVersion + " (G+)"
 	}
 	highestWinRune := common.RuneItem{
-		Alias:           champion.Id,
-		Name:            runeTitlePrefix + " Highest Win" + runeTitleSuffix,
+		Alias: champion.Id,
+		Name:  runeTitlePrefix + " Highest Win" + runeTitleSuffix,
+		Names: deps.Localized.FormatIn(champion.Id, func(locale string, name string) string {
+			return runeTitlePrefix + " " + name + " " + common.Phrase(locale, "Highest Win") + runeTitleSuffix
+		}),
 		Position:        curLane,
 		WinRate:         fmt.Sprintf("%v%%", resp.Summary.Runes.Win.Wr),
 		SelectedPerkIds: concatRuneIds(resp.Summary.Runes.Win.Set.Pri, resp.Summary.Runes.Win.Set.Sec, resp.Summary.Runes.Win.Set.Mod),
-		PrimaryStyleId:  common.GetPrimaryIdForRune(resp.Summary.Runes.Win.Set.Pri[0], runeLookUp),
-		SubStyleId:      common.GetPrimaryIdForRune(resp.Summary.Runes.Win.Set.Sec[0], runeLookUp),
+		PrimaryStyleId:  styleOf(resp.Summary.Runes.Win.Set.Pri, deps.RuneLookUp),
+		SubStyleId:      styleOf(resp.Summary.Runes.Win.Set.Sec, deps.RuneLookUp),
 		PickCount:       resp.Summary.Runes.Win.N,
 	}
 	defaultBuild.Runes = append(defaultBuild.Runes, highestWinRune)
 	mostCommonRune := common.RuneItem{
-		Alias:           champion.Id,
-		Name:            runeTitlePrefix + " Most Common" + runeTitleSuffix,
+		Alias: champion.Id,
+		Name:  runeTitlePrefix + " Most Common" + runeTitleSuffix,
+		Names: deps.Localized.FormatIn(champion.Id, func(locale string, name string) string {
+			return runeTitlePrefix + " " + name + " " + common.Phrase(locale, "Most Common") + runeTitleSuffix
+		}),
 		Position:        curLane,
 		WinRate:         fmt.Sprintf("%v%%", resp.Summary.Runes.Pick.Wr),
 		SelectedPerkIds: concatRuneIds(resp.Summary.Runes.Pick.Set.Pri, resp.Summary.Runes.Pick.Set.Sec, resp.Summary.Runes.Pick.Set.Mod),
-		PrimaryStyleId:  common.GetPrimaryIdForRune(resp.Summary.Runes.Pick.Set.Pri[0], runeLookUp),
-		SubStyleId:      common.GetPrimaryIdForRune(resp.Summary.Runes.Pick.Set.Sec[0], runeLookUp),
+		PrimaryStyleId:  styleOf(resp.Summary.Runes.Pick.Set.Pri, deps.RuneLookUp),
+		SubStyleId:      styleOf(resp.Summary.Runes.Pick.Set.Sec, deps.RuneLookUp),
 		PickCount:       resp.Summary.Runes.Pick.N,
 	}
 	defaultBuild.Runes = append(defaultBuild.Runes, mostCommonRune)
 
 	builds = append(builds, defaultBuild)
 
+	var issues []common.Issue
 	if fetchMore && !aram {
 		var restLanes []string
 		for _, lane := range common.GetKeys(resp.Nav.Lanes) {
@@ -228,69 +260,58 @@ func makeBuild(champion common.ChampionItem, query string, sourceVersion string,
 			}
 		}
 
-		if len(restLanes) > 0 {
-			wg := new(sync.WaitGroup)
-			ch := make(chan []common.ChampionDataItem, len(restLanes))
-
-			for _, l := range restLanes {
-				wg.Add(1)
-
-				go func(champion common.ChampionItem, query string, sourceVersion string, timestamp int64, cnt int, l string) {
-					q := query + "&lane=" + l
-					r, _ := makeBuild(champion, q, sourceVersion, officialVer, timestamp, cnt, false, runeLookUp, aram)
-					if r != nil {
-						ch <- *r
-					}
-
-					wg.Done()
-				}(champion, query, sourceVersion, timestamp, cnt, l)
+		// extra lanes are fetched one by one, as the current job already takes a slot of the pool
+		sort.Strings(restLanes)
+		for _, l := range restLanes {
+			q := query + "&lane=" + l
+			r, _, err := makeBuild(ctx, deps, champion, q, sourceVersion, cnt, false, aram)
+			if err != nil && ctx.Err() != nil {
+				// cancelled, the remaining lanes are not attempted
+				break
 			}
-
-			wg.Wait()
-			close(ch)
-
-			for d := range ch {
-				builds = append(builds, d...)
+			if err != nil {
+				issues = append(issues, common.NewIssue(champion.Id, l, err))
+				continue
 			}
+			builds = append(builds, *r...)
 		}
 	}
 
-	additionalText := ""
-	if aram {
-		additionalText = "(ARAM mode)"
-	}
-	fmt.Printf("[lolalytics] No.%d Fetched: %s@%s %s\n", cnt, champion.Name, curLane, additionalText)
-	return &builds, nil
+	deps.Logger.Debug("fetched champion", "index", cnt, "champion", champion.Id, "position", curLane)
+	return &builds, issues, nil
 }
 
-func Import(championAliasList map[string]common.ChampionItem, officialVer string, timestamp int64, runeLookUp common.IRuneLookUp, aram bool, debug bool) string {
-	start := time.Now()
+func Import(ctx context.Context, deps *common.Deps, aram bool) (*common.Result, error) {
+	pkgName := PkgName
 	if aram {
-		fmt.Println("🌉 [lolalytics-aram]: Start...")
-	} else {
-		fmt.Println("🌉 [lolalytics]: Start...")
+		pkgName = AramPkgName
 	}
+	result := common.NewResult(pkgName)
+	deps.Logger.Info("start")
 
 	buildUrl := "https://lolalytics.com/lol/rengar/build/"
 	if aram {
 		buildUrl = "https://lolalytics.com/lol/rengar/aram/build/"
 	}
 	// get initial patch version/ep etc.
-	body, err := common.MakeRequest(buildUrl)
+	body, err := deps.Fetcher.MakeRequest(ctx, buildUrl)
 	if err != nil {
-		return err.Error()
+		return nil, err
 	}
 
 	html := string(body)
 	eps := epReg.FindAllStringSubmatch(html, -1) // "ep=champion&p=d&v=9&patch=11.9&cid=107&lane=default&tier=platinum_plus&queue=420&region=all"
+	if len(eps) == 0 {
+		return nil, errors.New("lolalytics: api query not found in " + buildUrl)
+	}
 	epQuery := eps[0][0]
 	sourceVersion := getSourceVersion(epQuery)
 	queryMaker := makeQuery(epQuery)
 
 	q := queryMaker("103", "middle", "gold_plus")
-	tierList, err := getTierList(q)
+	tierList, err := getTierList(ctx, deps.Fetcher, q)
 	if err != nil {
-		return err.Error()
+		return nil, err
 	}
 
 	cIds := make([]string, 0, len(tierList.Cid))
@@ -298,52 +319,57 @@ func Import(championAliasList map[string]common.ChampionItem, officialVer string
 		cIds = append(cIds, key)
 	}
 
-	wg := new(sync.WaitGroup)
-	cnt := 0
-	ch := make(chan []common.ChampionDataItem, len(cIds))
+	sort.Strings(cIds)
+	if deps.Debug && len(cIds) > 7 {
+		cIds = cIds[:7]
+	}
 
-	for _, cid := range cIds {
-		if debug && cnt == 7 {
-			break
-		}
+	champions := make([]common.ChampionItem, len(cIds))
+	for i, cid := range cIds {
+		champions[i] = getChampionById(cid, deps.Champions)
+	}
 
-		if cnt > 0 && cnt%7 == 0 {
-			fmt.Println(`🌉 Take a break...`)
-			time.Sleep(time.Second * 5)
+	results := make([]*[]common.ChampionDataItem, len(cIds))
+	laneIssues := make([][]common.Issue, len(cIds))
+	errs := common.NewPool(deps.Concurrency).Run(ctx, len(cIds), func(ctx context.Context, i int) error {
+		if len(champions[i].Key) == 0 {
+			return nil
 		}
 
-		cnt += 1
-		wg.Add(1)
-
-		champion := getChampionById(cid, championAliasList)
-		query := queryMaker(cid, "default", "gold_plus")
-
-		go func() {
-			builds, err := makeBuild(champion, query, sourceVersion, officialVer, timestamp, cnt, true, runeLookUp, aram)
-			if err == nil {
-				ch <- *builds
-			}
-
-			wg.Done()
-		}()
-	}
-
-	wg.Wait()
-	close(ch)
+		query := queryMaker(cIds[i], "default", "gold_plus")
+		var err error
+		results[i], laneIssues[i], err = makeBuild(ctx, deps, champions[i], query, sourceVersion, i+1, true, aram)
+		return err
+	})
 
+	// every lane of a champion is a job
 	var data [][]common.ChampionDataItem
-	for i := range ch {
-		data = append(data, i)
-	}
-	pkgName := `lolalytics`
-	if aram {
-		pkgName = `lolalytics-aram`
-	}
-	common.Write2Folder(data, pkgName, timestamp, sourceVersion, officialVer)
+	runeValidator := common.NewRuneValidator(deps.RuneLookUp, deps.AllRunes)
+	itemValidator := common.NewItemValidator(deps.Items)
+	for i, builds := range results {
+		if len(champions[i].Key) == 0 {
+			result.Skip(cIds[i], "", "unknown champion id")
+			continue
+		}
+		if errs[i] != nil {
+			result.Attempted += 1
+			result.Fail(ctx, champions[i].Id, "", errs[i])
+			continue
+		}
 
-	duration := time.Since(start)
-	if aram {
-		return fmt.Sprintf("🟢 [lolalytics-aram] Finished, took: %s.", duration)
+		result.Attempted += len(*builds) + len(laneIssues[i])
+		for j := range *builds {
+			b := &(*builds)[j]
+			result.Succeed(b.Alias, b.Position)
+			runeValidator.DropInvalid(result, b)
+			itemValidator.DropInvalid(result, b)
+		}
+		result.Failed = append(result.Failed, laneIssues[i]...)
+		data = append(data, *builds)
 	}
-	return fmt.Sprintf("🟢 [lolalytics] Finished, took: %s.", duration)
+	err = common.Write2Folder(data, pkgName, deps.Timestamp, sourceVersion, deps.OfficialVersion, deps.OfficialVersion)
+
+	result.SourceVersion = sourceVersion
+	result.Finish()
+	return result, err
 }
@@ -0,0 +1,43 @@
+package lolalytics
+
+import (
+	"context"
+	"data-crawler/pkg/common"
+)
+
+type source struct {
+	aram bool
+}
+
+func init() {
+	common.RegisterSource(source{})
+	common.RegisterSource(source{aram: true})
+}
+
+func (s source) Name() string {
+	return `lolalytics`
+}
+
+func (s source) PkgName() string {
+	if s.aram {
+		return AramPkgName
+	}
+	return PkgName
+}
+
+func (s source) Modes() []common.Mode {
+	if s.aram {
+		return []common.Mode{common.ModeAram}
+	}
+	return []common.Mode{common.ModeClassic}
+}
+
+func (s source) Fetch(ctx context.Context, deps *common.Deps) (*common.Result, error) {
+	result, err := Import(ctx, deps, s.aram)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Source = s.Name()
+	return result, nil
+}
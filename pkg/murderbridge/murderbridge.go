@@ -1,14 +1,12 @@
 package murderbridge
 
 import (
+	"context"
 	"data-crawler/pkg/common"
 	"encoding/json"
-	"fmt"
 	"math"
 	"sort"
 	"strconv"
-	"sync"
-	"time"
 )
 
 type VersionResp struct {
@@ -83,9 +81,9 @@ var items *map[string]common.BuildItem
 var runeLoopUp map[int]*common.RespRuneItem
 var allRunes *[]common.RuneSlot
 
-func getLatestVersion() (string, error) {
+func getLatestVersion(ctx context.Context, f *common.Fetcher) (string, error) {
 	url := MurderBridgeBUrl + `/save/general.json`
-	body, err := common.MakeRequest(url)
+	body, err := f.MakeRequest(ctx, url)
 	if err != nil {
 		return "", err
 	}
@@ -319,9 +317,17 @@ func generateOptimalPerks(runes map[string]StatItem) []PerkStyleItem {
 	return result
 }
 
-func genChampionData(champion common.ChampionItem, version string, timestamp int64) (*common.ChampionDataItem, error) {
+// runeName names a rune page of champion `name` after its keystone.
+func runeName(name string, keystone string) string {
+	if len(keystone) == 0 {
+		return `[MB] ` + name
+	}
+	return `[MB] ` + name + ` - ` + keystone
+}
+
+func genChampionData(ctx context.Context, f *common.Fetcher, l *common.Localized, logger *common.Logger, champion common.ChampionItem, version string, timestamp int64) (*common.ChampionDataItem, error) {
 	url := MurderBridgeBUrl + `/save/` + version + `/ARAM/` + champion.Id + `.json`
-	body, err := common.MakeRequest(url)
+	body, err := f.MakeRequest(ctx, url)
 	if err != nil {
 		return nil, err
 	}
@@ -331,6 +337,7 @@ func genChampionData(champion common.ChampionItem, version string, timestamp int
 		Version:   version,
 		Alias:     champion.Id,
 		Name:      champion.Name,
+		Names:     l.ChampionNames(champion.Id),
 		Timestamp: timestamp,
 	}
 	var data ChampionDataResp
@@ -348,14 +355,32 @@ func genChampionData(champion common.ChampionItem, version string, timestamp int
 		StartedFrom:         "blank",
 		Type:                "custom",
 		Blocks:              makeBlocks(data),
+		Titles: l.Format(champion.Id, func(name string) string {
+			return `[MB] ` + name + ` ` + version
+		}),
+	}
+	for i := range build.Blocks {
+		build.Blocks[i].Types = l.BlockTypes(build.Blocks[i].Type)
 	}
 	result.ItemBuilds = append(result.ItemBuilds, build)
 
 	optimalRunes := generateOptimalPerks(data.Runes)
 	for _, r := range optimalRunes {
+		// pages are told apart by their keystone
+		var keystone int
+		var keystoneName string
+		if len(r.Runes) > 0 {
+			keystone = r.Runes[0]
+		}
+		if k, ok := runeLoopUp[keystone]; ok {
+			keystoneName = k.Name
+		}
 		item := common.RuneItem{
-			Alias:          champion.Id,
-			Name:           `[MB] ` + champion.Name,
+			Alias: champion.Id,
+			Name:  runeName(champion.Name, keystoneName),
+			Names: l.FormatIn(champion.Id, func(locale string, name string) string {
+				return runeName(name, l.RuneName(locale, keystone))
+			}),
 			Position:       ``,
 			PrimaryStyleId: r.Style,
 			SubStyleId:     r.SubStyle,
@@ -373,52 +398,55 @@ func genChampionData(champion common.ChampionItem, version string, timestamp int
 		result.Runes = append(result.Runes, item)
 	}
 
-	fmt.Printf("🤪 [MB] %s: Fetched data. \n", result.Alias)
+	logger.Debug("fetched champion", "champion", result.Alias)
 	return &result, nil
 }
 
-func Import(championAliasList map[string]common.ChampionItem, timestamp int64, rLookUp common.IRuneLookUp, runes common.IAllRunes, debug bool) string {
-	start := time.Now()
-	fmt.Println("🌉 [MB]: Start...")
-
-	ver, _ := getLatestVersion()
-	items, _ = common.GetItemList(ver)
-	runeLoopUp, allRunes = rLookUp, runes
+func Import(ctx context.Context, deps *common.Deps) (*common.Result, error) {
+	result := common.NewResult(MurderBridge)
+	deps.Logger.Info("start")
 
-	wg := new(sync.WaitGroup)
-	cnt := 0
-	ch := make(chan common.ChampionDataItem, len(championAliasList))
-	for _, champion := range championAliasList {
-		if debug && cnt > 5 {
-			break
-		}
-		if cnt > 0 && cnt%7 == 0 {
-			fmt.Println(`🌉 Take a break...`)
-			time.Sleep(time.Second * 5)
-		}
+	ver, err := getLatestVersion(ctx, deps.Fetcher)
+	if err != nil {
+		return nil, err
+	}
+	// items of the official version, MB's own version may not be on Data Dragon or in the dragontail
+	items, runeLoopUp, allRunes = deps.Items, deps.RuneLookUp, deps.AllRunes
 
-		cnt += 1
-		wg.Add(1)
-		go func(_champion common.ChampionItem, _ver string, _cnt int, _timestamp int64) {
-			d, err := genChampionData(_champion, _ver, timestamp)
-			if d != nil {
-				ch <- *d
-			} else {
-				fmt.Println(_champion.Id, err)
-			}
-			wg.Done()
-		}(champion, ver, cnt, timestamp)
+	aliases := common.GetKeys(deps.Champions)
+	sort.Strings(aliases)
+	if deps.Debug && len(aliases) > 6 {
+		aliases = aliases[:6]
 	}
-	wg.Wait()
-	close(ch)
 
+	results := make([]*common.ChampionDataItem, len(aliases))
+	errs := common.NewPool(deps.Concurrency).Run(ctx, len(aliases), func(ctx context.Context, i int) error {
+		var err error
+		results[i], err = genChampionData(ctx, deps.Fetcher, deps.Localized, deps.Logger, deps.Champions[aliases[i]], ver, deps.Timestamp)
+		if err != nil {
+			deps.Logger.Warn("fetch champion failed", "champion", aliases[i], "error", err)
+		}
+		return err
+	})
+
+	result.Attempted = len(aliases)
 	var data [][]common.ChampionDataItem
-	for i := range ch {
-		content := []common.ChampionDataItem{i}
-		data = append(data, content)
+	runeValidator := common.NewRuneValidator(deps.RuneLookUp, deps.AllRunes)
+	itemValidator := common.NewItemValidator(deps.Items)
+	for i, d := range results {
+		if errs[i] != nil {
+			result.Fail(ctx, aliases[i], "", errs[i])
+			continue
+		}
+
+		result.Succeed(aliases[i], "")
+		runeValidator.DropInvalid(result, d)
+		itemValidator.DropInvalid(result, d)
+		data = append(data, []common.ChampionDataItem{*d})
 	}
-	common.Write2Folder(data, MurderBridge, timestamp, ver, ver)
+	err = common.Write2Folder(data, MurderBridge, deps.Timestamp, ver, ver, deps.OfficialVersion)
 
-	duration := time.Since(start)
-	return fmt.Sprintf("🟢 [MB] Finished. Took %s.", duration)
+	result.SourceVersion = ver
+	result.Finish()
+	return result, err
 }
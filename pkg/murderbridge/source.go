@@ -0,0 +1,34 @@
+package murderbridge
+
+import (
+	"context"
+	"data-crawler/pkg/common"
+)
+
+type source struct{}
+
+func init() {
+	common.RegisterSource(source{})
+}
+
+func (s source) Name() string {
+	return MurderBridge
+}
+
+func (s source) PkgName() string {
+	return MurderBridge
+}
+
+func (s source) Modes() []common.Mode {
+	return []common.Mode{common.ModeAram}
+}
+
+func (s source) Fetch(ctx context.Context, deps *common.Deps) (*common.Result, error) {
+	result, err := Import(ctx, deps)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Source = s.Name()
+	return result, nil
+}
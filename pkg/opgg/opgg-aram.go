@@ -1,26 +1,23 @@
 package opgg
 
 import (
+	"context"
 	"data-crawler/pkg/common"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
-	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
 )
 
-func genData(alias string, id int, version string) (*common.ChampionDataItem, error) {
+func genData(ctx context.Context, f *common.Fetcher, l *common.Localized, alias string, id int, version string) (*common.ChampionDataItem, error) {
 	url := AramSourceUrl + "/" + alias + "/statistics"
 
-	doc, err := common.ParseHTML(url)
+	doc, err := f.ParseHTML(ctx, url)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	d := common.ChampionDataItem{
@@ -50,6 +47,9 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 		Sortrank:            1,
 		StartedFrom:         "blank",
 		Type:                "custom",
+		Titles: l.Format(alias, func(name string) string {
+			return "[OP.GG-ARAM] " + name + " " + version
+		}),
 	}
 
 	// item builds
@@ -62,7 +62,10 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 
 			pickCnt := strings.ReplaceAll(selection.Find(`td.champion-overview__stats--pick.champion-overview__border > span`).Text(), `,`, ``)
 			winRate := selection.Find(`td.champion-overview__stats--win.champion-overview__border > strong`).Text()
-			firstBlock.Type = `Recommended build: Pick ` + pickCnt + `, Win Rate ` + winRate
+			firstBlock.Type = recommendedBlockType(common.DefaultLocale, pickCnt, winRate)
+			firstBlock.Types = l.Each(func(locale string) string {
+				return recommendedBlockType(locale, pickCnt, winRate)
+			})
 			selection.Find("li.champion-stats__list__item img").Each(func(i int, img *goquery.Selection) {
 				src, _ := img.Attr("src")
 				id := common.MatchId(src)
@@ -79,7 +82,10 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 				winRate := tr.Find(`td.champion-overview__stats--win.champion-overview__border > strong`).Text()
 
 				var block common.ItemBuildBlockItem
-				block.Type = `Recommended build: Pick ` + pickCnt + `, Win Rate ` + winRate
+				block.Type = recommendedBlockType(common.DefaultLocale, pickCnt, winRate)
+				block.Types = l.Each(func(locale string) string {
+					return recommendedBlockType(locale, pickCnt, winRate)
+				})
 
 				tr.Find("li.champion-stats__list__item img").Each(func(i int, img *goquery.Selection) {
 					src, _ := img.Attr("src")
@@ -98,6 +104,7 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 
 		var block common.ItemBuildBlockItem
 		block.Type = blockType
+		block.Types = l.BlockTypes(blockType)
 
 		var itemIds []string
 		selection.Find("li.champion-stats__list__item img").Each(func(i int, img *goquery.Selection) {
@@ -123,7 +130,8 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 
 	// consumables
 	b := common.ItemBuildBlockItem{
-		Type: "Consumables",
+		Type:  "Consumables",
+		Types: l.BlockTypes("Consumables"),
 	}
 	for _, id := range common.ConsumableItems {
 		item := common.BlockItem{
@@ -164,6 +172,9 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 		runeItem.WinRate = tr.Find(`.champion-overview__stats--pick .win-ratio__text`).Next().Text()
 
 		runeItem.Name = "[OP.GG-ARAM] " + alias + " - " + runeItem.WinRate + ", " + fmt.Sprint(runeItem.PickCount)
+		runeItem.Names = l.Format(alias, func(name string) string {
+			return "[OP.GG-ARAM] " + name + " - " + runeItem.WinRate + ", " + fmt.Sprint(runeItem.PickCount)
+		})
 
 		d.Runes = append(d.Runes, runeItem)
 	})
@@ -175,87 +186,95 @@ func genData(alias string, id int, version string) (*common.ChampionDataItem, er
 	return &d, nil
 }
 
-func startJob(champ ChampionListItem, index int, version string) *common.ChampionDataItem {
-	time.Sleep(time.Second * 1)
-
+func startJob(ctx context.Context, f *common.Fetcher, l *common.Localized, logger *common.Logger, champ ChampionListItem, index int, version string) (*common.ChampionDataItem, error) {
 	alias := champ.Alias
-	// fmt.Printf("⌛ [OP.GG-ARAM]️️ No.%d, %s @ %s\n", index, alias, position)
 
 	id, _ := strconv.Atoi(champ.Id)
-	d, _ := genData(alias, id, version)
-	if d != nil {
-		d.Index = index
-		d.Id = champ.Id
-		d.Name = champ.Name
+	d, err := genData(ctx, f, l, alias, id, version)
+	if err != nil {
+		logger.Warn("fetch champion failed", "index", index, "champion", alias, "error", err)
+		return nil, err
 	}
 
-	fmt.Printf("🌟 [OP.GG-ARAM] No.%d, %s \n", index, alias)
-	return d
-}
+	d.Index = index
+	d.Id = champ.Id
+	d.Name = champ.Name
+	d.Names = l.ChampionNames(alias)
 
-func ImportAram(allChampions map[string]common.ChampionItem, aliasList map[string]string, officialVer string, timestamp int64, debug bool) string {
-	start := time.Now()
-	fmt.Println("🤖 [OP.GG-ARAM] Start...")
-
-	d, count := genOverview(allChampions, aliasList, true)
-	fmt.Printf("🤪 [OP.GG-ARAM] Got champions & positions, count: %d \n", count)
-
-	wg := new(sync.WaitGroup)
-	cnt := 0
-	ch := make(chan common.ChampionDataItem, count)
-
-listLoop:
-	for _, cur := range d.ChampionList {
-		cnt += 1
+	logger.Debug("fetched champion", "index", index, "champion", alias)
+	return d, nil
+}
 
-		if cnt%7 == 0 {
-			if debug {
-				wg.Done()
-				break listLoop
-			}
-			time.Sleep(time.Second * 5)
-		}
+func ImportAram(ctx context.Context, deps *common.Deps) (*common.Result, error) {
+	result := common.NewResult(AramPkgName)
+	deps.Logger.Info("start")
 
-		wg.Add(1)
-		go func(_cur ChampionListItem, _cnt int, _ver string) {
-			ch <- *startJob(_cur, _cnt, _ver)
-			wg.Done()
-		}(cur, cnt, officialVer)
+	d, count, err := genOverview(ctx, deps.Fetcher, deps.Champions, deps.AliasList, true)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range d.Unknown {
+		result.Skip(name, "", "unknown champion name")
 	}
+	deps.Logger.Info("got champions", "count", count)
 
-	wg.Wait()
-	close(ch)
+	champions := d.ChampionList
+	if deps.Debug && len(champions) > 6 {
+		champions = champions[:6]
+	}
 
-	outputPath := filepath.Join(".", "output", AramPkgName)
-	_ = os.MkdirAll(outputPath, os.ModePerm)
+	results := make([]*common.ChampionDataItem, len(champions))
+	errs := common.NewPool(deps.Concurrency).Run(ctx, len(champions), func(ctx context.Context, i int) error {
+		var err error
+		results[i], err = startJob(ctx, deps.Fetcher, deps.Localized, deps.Logger, champions[i], i+1, deps.OfficialVersion)
+		return err
+	})
 
-	failed := 0
+	result.Attempted = len(champions)
 	r := make(map[string][]common.ChampionDataItem)
-
-	for champion := range ch {
-		if champion.Skills != nil {
-			champion.Timestamp = timestamp
-			champion.Version = d.Version
-			champion.OfficialVersion = officialVer
-			r[champion.Alias] = append(r[champion.Alias], champion)
+	runeValidator := common.NewRuneValidator(deps.RuneLookUp, deps.AllRunes)
+	itemValidator := common.NewItemValidator(deps.Items)
+
+	for i, champion := range results {
+		alias := champions[i].Alias
+		if errs[i] != nil {
+			result.Fail(ctx, alias, "", errs[i])
+			continue
 		}
+		if champion.Skills == nil {
+			result.Skip(alias, "", "no skill data on page")
+			continue
+		}
+
+		result.Succeed(alias, "")
+		champion.Timestamp = deps.Timestamp
+		champion.Version = d.Version
+		champion.OfficialVersion = deps.OfficialVersion
+		runeValidator.DropInvalid(result, champion)
+		itemValidator.DropInvalid(result, champion)
+		r[champion.Alias] = append(r[champion.Alias], *champion)
 	}
 
+	outputPath := common.StagingDir(AramPkgName)
+	if err = os.MkdirAll(outputPath, os.ModePerm); err != nil {
+		return result, err
+	}
 	for k, v := range r {
-		fileName := outputPath + "/" + k + ".json"
-		_ = common.SaveJSON(fileName, v)
+		fileName := filepath.Join(outputPath, k+".json")
+		if err = common.SaveJSON(fileName, v); err != nil {
+			return result, err
+		}
 	}
 
-	_ = common.SaveJSON("output/index.json", allChampions)
-
-	pkg, _ := common.GenPkgInfo("tpl/package.json", common.PkgInfo{
-		Timestamp:       timestamp,
-		SourceVersion:   d.Version,
-		OfficialVersion: officialVer,
-		PkgName:         AramPkgName,
+	err = common.WritePkgInfo(common.PkgInfo{
+		Timestamp:         deps.Timestamp,
+		SourceVersion:     d.Version,
+		OfficialVersion:   deps.OfficialVersion,
+		DataDragonVersion: deps.OfficialVersion,
+		PkgName:           AramPkgName,
 	})
-	_ = ioutil.WriteFile("output/"+AramPkgName+"/package.json", []byte(pkg), 0644)
 
-	duration := time.Since(start)
-	return fmt.Sprintf("🟢 [OP.GG-ARAM] All finished, success: %d, failed: %d, took %s", cnt-failed, failed, duration)
+	result.SourceVersion = d.Version
+	result.Finish()
+	return result, err
 }
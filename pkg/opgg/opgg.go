@@ -1,21 +1,18 @@
 package opgg
 
 import (
+	"context"
 	"data-crawler/pkg/common"
 	"fmt"
 	"github.com/PuerkitoBio/goquery"
-	"io/ioutil"
-	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-	"sync"
-	"time"
 )
 
-func genPositionData(alias string, position string, id int, version string) (*common.ChampionDataItem, error) {
+func genPositionData(ctx context.Context, f *common.Fetcher, l *common.Localized, alias string, position string, id int, version string) (*common.ChampionDataItem, error) {
 	pos := position
 	if position == `middle` {
 		pos = `mid`
@@ -24,9 +21,9 @@ func genPositionData(alias string, position string, id int, version string) (*co
 	}
 	url := SourceUrl + "/" + alias + "/statistics/" + pos
 
-	doc, err := common.ParseHTML(url)
+	doc, err := f.ParseHTML(ctx, url)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	d := common.ChampionDataItem{
@@ -57,6 +54,9 @@ func genPositionData(alias string, position string, id int, version string) (*co
 		Sortrank:            1,
 		StartedFrom:         "blank",
 		Type:                "custom",
+		Titles: l.Format(alias, func(name string) string {
+			return "[OP.GG] " + name + " @ " + position + ` ` + version
+		}),
 	}
 
 	// item builds
@@ -69,7 +69,10 @@ func genPositionData(alias string, position string, id int, version string) (*co
 
 			pickCnt := strings.ReplaceAll(selection.Find(`td.champion-overview__stats--pick.champion-overview__border > span`).Text(), `,`, ``)
 			winRate := selection.Find(`td.champion-overview__stats--win.champion-overview__border > strong`).Text()
-			firstBlock.Type = `Recommended build: Pick ` + pickCnt + `, Win Rate ` + winRate
+			firstBlock.Type = recommendedBlockType(common.DefaultLocale, pickCnt, winRate)
+			firstBlock.Types = l.Each(func(locale string) string {
+				return recommendedBlockType(locale, pickCnt, winRate)
+			})
 			selection.Find("li.champion-stats__list__item img").Each(func(i int, img *goquery.Selection) {
 				src, _ := img.Attr("src")
 				id := common.MatchId(src)
@@ -86,7 +89,10 @@ func genPositionData(alias string, position string, id int, version string) (*co
 				winRate := tr.Find(`td.champion-overview__stats--win.champion-overview__border > strong`).Text()
 
 				var block common.ItemBuildBlockItem
-				block.Type = `Recommended build: Pick ` + pickCnt + `, Win Rate ` + winRate
+				block.Type = recommendedBlockType(common.DefaultLocale, pickCnt, winRate)
+				block.Types = l.Each(func(locale string) string {
+					return recommendedBlockType(locale, pickCnt, winRate)
+				})
 
 				tr.Find("li.champion-stats__list__item img").Each(func(i int, img *goquery.Selection) {
 					src, _ := img.Attr("src")
@@ -105,6 +111,7 @@ func genPositionData(alias string, position string, id int, version string) (*co
 
 		var block common.ItemBuildBlockItem
 		block.Type = blockType
+		block.Types = l.BlockTypes(blockType)
 
 		var itemIds []string
 		selection.Find("li.champion-stats__list__item img").Each(func(i int, img *goquery.Selection) {
@@ -143,7 +150,8 @@ func genPositionData(alias string, position string, id int, version string) (*co
 
 	// consumables
 	b := common.ItemBuildBlockItem{
-		Type: "Consumables",
+		Type:  "Consumables",
+		Types: l.BlockTypes("Consumables"),
 	}
 	for _, id := range common.ConsumableItems {
 		item := common.BlockItem{
@@ -185,6 +193,9 @@ func genPositionData(alias string, position string, id int, version string) (*co
 		runeItem.WinRate = tr.Find(`.champion-overview__stats--pick .win-ratio__text`).Next().Text()
 
 		runeItem.Name = "[OP.GG] " + alias + "@" + position + " - " + runeItem.WinRate + ", " + fmt.Sprint(runeItem.PickCount)
+		runeItem.Names = l.Format(alias, func(name string) string {
+			return "[OP.GG] " + name + "@" + position + " - " + runeItem.WinRate + ", " + fmt.Sprint(runeItem.PickCount)
+		})
 
 		d.Runes = append(d.Runes, runeItem)
 	})
@@ -196,89 +207,103 @@ func genPositionData(alias string, position string, id int, version string) (*co
 	return &d, nil
 }
 
-func worker(champ ChampionListItem, position string, index int, version string) *common.ChampionDataItem {
-	time.Sleep(time.Second * 1)
-
+func worker(ctx context.Context, f *common.Fetcher, l *common.Localized, logger *common.Logger, champ ChampionListItem, position string, index int, version string) (*common.ChampionDataItem, error) {
 	alias := champ.Alias
-	// fmt.Printf("⌛ [OP.GG]️️ No.%d, %s @ %s\n", index, alias, position)
 
 	id, _ := strconv.Atoi(champ.Id)
-	d, _ := genPositionData(alias, position, id, version)
-	if d != nil {
-		d.Index = index
-		d.Id = champ.Id
-		d.Name = champ.Name
+	d, err := genPositionData(ctx, f, l, alias, position, id, version)
+	if err != nil {
+		logger.Warn("fetch champion failed", "index", index, "champion", alias, "position", position, "error", err)
+		return nil, err
 	}
 
-	fmt.Printf("🌟 [OP.GG] No.%d, %s @ %s\n", index, alias, position)
-	return d
-}
+	d.Index = index
+	d.Id = champ.Id
+	d.Name = champ.Name
+	d.Names = l.ChampionNames(alias)
 
-func Import(allChampions map[string]common.ChampionItem, aliasList map[string]string, officialVer string, timestamp int64, debug bool) string {
-	start := time.Now()
-	fmt.Println("🤖 [OP.GG] Start...")
+	logger.Debug("fetched champion", "index", index, "champion", alias, "position", position)
+	return d, nil
+}
 
-	d, count := genOverview(allChampions, aliasList, false)
-	fmt.Printf("🤪 [OP.GG] Got champions & positions, count: %d \n", count)
+func Import(ctx context.Context, deps *common.Deps) (*common.Result, error) {
+	result := common.NewResult(PkgName)
+	deps.Logger.Info("start")
 
-	wg := new(sync.WaitGroup)
-	cnt := 0
-	ch := make(chan common.ChampionDataItem, count)
+	d, count, err := genOverview(ctx, deps.Fetcher, deps.Champions, deps.AliasList, false)
+	if err != nil {
+		return nil, err
+	}
+	for _, alias := range d.Unavailable {
+		result.Skip(alias, "", "no position available")
+	}
+	for _, name := range d.Unknown {
+		result.Skip(name, "", "unknown champion name")
+	}
+	deps.Logger.Info("got champions & positions", "count", count)
 
-listLoop:
+	var jobs []job
 	for _, cur := range d.ChampionList {
 		for _, p := range cur.Positions {
-			cnt += 1
-
-			if cnt%7 == 0 {
-				if debug {
-					wg.Done()
-					break listLoop
-				}
-				time.Sleep(time.Second * 5)
-			}
-
-			wg.Add(1)
-			go func(_cur ChampionListItem, _p string, _cnt int, _ver string) {
-				ch <- *worker(_cur, _p, _cnt, _ver)
-				wg.Done()
-			}(cur, p, cnt, officialVer)
+			jobs = append(jobs, job{champion: cur, position: p})
 		}
 	}
+	if deps.Debug && len(jobs) > 6 {
+		jobs = jobs[:6]
+	}
 
-	wg.Wait()
-	close(ch)
-
-	outputPath := filepath.Join(".", "output", PkgName)
-	_ = os.MkdirAll(outputPath, os.ModePerm)
+	results := make([]*common.ChampionDataItem, len(jobs))
+	errs := common.NewPool(deps.Concurrency).Run(ctx, len(jobs), func(ctx context.Context, i int) error {
+		var err error
+		results[i], err = worker(ctx, deps.Fetcher, deps.Localized, deps.Logger, jobs[i].champion, jobs[i].position, i+1, deps.OfficialVersion)
+		return err
+	})
 
-	failed := 0
+	result.Attempted = len(jobs)
 	r := make(map[string][]common.ChampionDataItem)
-
-	for champion := range ch {
-		if champion.Skills != nil {
-			champion.Timestamp = timestamp
-			champion.Version = d.Version
-			champion.OfficialVersion = officialVer
-			r[champion.Alias] = append(r[champion.Alias], champion)
+	runeValidator := common.NewRuneValidator(deps.RuneLookUp, deps.AllRunes)
+	itemValidator := common.NewItemValidator(deps.Items)
+
+	for i, champion := range results {
+		alias, position := jobs[i].champion.Alias, jobs[i].position
+		if errs[i] != nil {
+			result.Fail(ctx, alias, position, errs[i])
+			continue
 		}
+		if champion.Skills == nil {
+			result.Skip(alias, position, "no skill data on page")
+			continue
+		}
+
+		result.Succeed(alias, position)
+		champion.Timestamp = deps.Timestamp
+		champion.Version = d.Version
+		champion.OfficialVersion = deps.OfficialVersion
+		runeValidator.DropInvalid(result, champion)
+		itemValidator.DropInvalid(result, champion)
+		r[champion.Alias] = append(r[champion.Alias], *champion)
 	}
 
+	outputPath := common.StagingDir(PkgName)
+	if err = os.MkdirAll(outputPath, os.ModePerm); err != nil {
+		return result, err
+	}
 	for k, v := range r {
-		fileName := outputPath + "/" + k + ".json"
-		_ = common.SaveJSON(fileName, v)
+		fileName := filepath.Join(outputPath, k+".json")
+		if err = common.SaveJSON(fileName, v); err != nil {
+			return result, err
+		}
 	}
 
-	_ = common.SaveJSON("output/index.json", allChampions)
-
-	pkg, _ := common.GenPkgInfo("tpl/package.json", common.PkgInfo{
-		Timestamp:       timestamp,
-		SourceVersion:   d.Version,
-		OfficialVersion: officialVer,
-		PkgName:         PkgName,
+	err = common.WritePkgInfo(common.PkgInfo{
+		Timestamp:         deps.Timestamp,
+		SourceVersion:     d.Version,
+		OfficialVersion:   deps.OfficialVersion,
+		DataDragonVersion: deps.OfficialVersion,
+		PkgName:           PkgName,
 	})
-	_ = ioutil.WriteFile("output/"+PkgName+"/package.json", []byte(pkg), 0644)
 
-	duration := time.Since(start)
-	return fmt.Sprintf("🟢 [OP.GG] All finished, success: %d, failed: %d, took %s", cnt-failed, failed, duration)
+	result.SourceVersion = d.Version
+	result.Finish()
+	return result, err
 }
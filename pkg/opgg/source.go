@@ -0,0 +1,49 @@
+package opgg
+
+import (
+	"context"
+	"data-crawler/pkg/common"
+)
+
+type source struct {
+	aram bool
+}
+
+func init() {
+	common.RegisterSource(source{})
+	common.RegisterSource(source{aram: true})
+}
+
+func (s source) Name() string {
+	return `opgg`
+}
+
+func (s source) PkgName() string {
+	if s.aram {
+		return AramPkgName
+	}
+	return PkgName
+}
+
+func (s source) Modes() []common.Mode {
+	if s.aram {
+		return []common.Mode{common.ModeAram}
+	}
+	return []common.Mode{common.ModeClassic}
+}
+
+func (s source) Fetch(ctx context.Context, deps *common.Deps) (*common.Result, error) {
+	var result *common.Result
+	var err error
+	if s.aram {
+		result, err = ImportAram(ctx, deps)
+	} else {
+		result, err = Import(ctx, deps)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Source = s.Name()
+	return result, nil
+}
@@ -11,4 +11,10 @@ type OverviewData struct {
 	Version      string             `json:"version"`
 	ChampionList []ChampionListItem `json:"championList"`
 	Unavailable  []string           `json:"unavailable"`
+	Unknown      []string           `json:"unknown"`
+}
+
+type job struct {
+	champion ChampionListItem
+	position string
 }
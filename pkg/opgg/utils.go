@@ -1,20 +1,20 @@
 package opgg
 
 import (
+	"context"
 	"data-crawler/pkg/common"
 	"github.com/PuerkitoBio/goquery"
-	"log"
 	"strings"
 )
 
-func genOverview(allChampions map[string]common.ChampionItem, aliasList map[string]string, aram bool) (*OverviewData, int) {
+func genOverview(ctx context.Context, f *common.Fetcher, allChampions map[string]common.ChampionItem, aliasList map[string]string, aram bool) (*OverviewData, int, error) {
 	url := SourceUrl
 	if aram {
 		url = AramSourceUrl
 	}
-	doc, err := common.ParseHTML(url + `/statistics`)
+	doc, err := f.ParseHTML(ctx, url+`/statistics`)
 	if err != nil {
-		log.Fatal(err)
+		return nil, 0, err
 	}
 
 	d := OverviewData{
@@ -29,7 +29,11 @@ func genOverview(allChampions map[string]common.ChampionItem, aliasList map[stri
 	count := 0
 	doc.Find(`.champion-index__champion-list .champion-index__champion-item`).Each(func(i int, s *goquery.Selection) {
 		name := s.Find(".champion-index__champion-item__name").Text()
-		alias := aliasList[name]
+		alias, ok := common.MatchAlias(aliasList, name)
+		if !ok {
+			d.Unknown = append(d.Unknown, name)
+			return
+		}
 
 		if aram {
 			c := ChampionListItem{Alias: alias, Name: name, Id: allChampions[alias].Key}
@@ -52,5 +56,11 @@ func genOverview(allChampions map[string]common.ChampionItem, aliasList map[stri
 		}
 	})
 
-	return &d, count
+	return &d, count, nil
+}
+
+// recommendedBlockType is the type of a recommended build block in `locale`.
+func recommendedBlockType(locale string, pickCnt string, winRate string) string {
+	return common.Phrase(locale, `Recommended build`) + `: ` + common.Phrase(locale, `Pick`) + ` ` + pickCnt +
+		`, ` + common.Phrase(locale, `Win Rate`) + ` ` + winRate
 }
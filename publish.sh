@@ -2,26 +2,8 @@
 
 args="${@}"
 
-npm=$(command -v npm)
-
 ./data-crawler $args
-cp output/index.json output/op.gg/
-cp output/index.json output/op.gg-aram/
-cp output/index.json output/murderbridge/
-cp output/index.json output/lolalytics/
-cp output/index.json output/lolalytics-aram/
-
-cd output/op.gg
-$npm publish --access public
-
-cd ../op.gg-aram
-$npm publish --access public
-
-cd ../murderbridge
-$npm publish --access public
-
-cd ../lolalytics
-$npm publish --access public
+./data-crawler validate output || exit 1
 
-cd ../lolalytics-aram
-$npm publish --access public
+# packages whose content is the same as the published one are skipped
+./data-crawler publish output